@@ -0,0 +1,190 @@
+// Package auth splits DID authentication between a verifier, such as a SAO
+// gateway, and a remote client: the verifier issues a Challenge, the client
+// answers it with Respond using its own types.DidProvider and the verifier
+// checks the answer and returns a Session.
+package auth
+
+import (
+	"sync"
+	"time"
+
+	did "github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/scope"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"github.com/SaoNetwork/sao-did/util"
+	"github.com/thanhpk/randstr"
+	"golang.org/x/xerrors"
+)
+
+// DefaultChallengeLifetime is how long a client has to answer a challenge.
+const DefaultChallengeLifetime = 5 * time.Minute
+
+// Challenge is sent by the verifier to the client.
+type Challenge struct {
+	Nonce string   `json:"nonce"`
+	Aud   string   `json:"aud"`
+	Paths []string `json:"paths,omitempty"`
+	// Exp is the unix time after which the challenge is not accepted.
+	Exp int64 `json:"exp"`
+}
+
+// Session describes an authenticated client.
+type Session struct {
+	Did   string
+	Aud   string
+	Paths []string
+	// ExpiresAt is the expiry of the authentication response.
+	ExpiresAt time.Time
+}
+
+// Respond answers the challenge on the client side.
+func Respond(provider saotypes.DidProvider, challenge Challenge) (saotypes.GeneralJWS, error) {
+	if provider == nil {
+		return saotypes.GeneralJWS{}, xerrors.New("provider is missing.")
+	}
+	if challenge.Nonce == "" || challenge.Aud == "" {
+		return saotypes.GeneralJWS{}, xerrors.New("invalid challenge: nonce and aud are required")
+	}
+	return provider.Authenticate(saotypes.AuthParams{
+		Aud:   challenge.Aud,
+		Nonce: challenge.Nonce,
+		Paths: challenge.Paths,
+	})
+}
+
+// Verifier issues challenges and verifies the responses. Issued challenges
+// are kept in memory and can be answered once.
+type Verifier struct {
+	// Aud identifies the verifier, clients sign it in their response.
+	Aud      string
+	Resolver saotypes.DidResolver
+	// Lifetime of the challenges, DefaultChallengeLifetime when zero.
+	Lifetime time.Duration
+	// Clock defaults to the system clock.
+	Clock saotypes.Clock
+	// ClockSkew is the allowed clock difference with clients.
+	ClockSkew time.Duration
+
+	mu      sync.Mutex
+	pending map[string]Challenge
+}
+
+func NewVerifier(aud string, resolver saotypes.DidResolver) *Verifier {
+	return &Verifier{Aud: aud, Resolver: resolver}
+}
+
+// NewChallenge issues a challenge requesting access to paths.
+func (v *Verifier) NewChallenge(paths []string) Challenge {
+	now := v.now()
+	lifetime := v.Lifetime
+	if lifetime == 0 {
+		lifetime = DefaultChallengeLifetime
+	}
+	challenge := Challenge{
+		Nonce: randstr.String(32),
+		Aud:   v.Aud,
+		Paths: paths,
+		Exp:   now.Add(lifetime).Unix(),
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.pending == nil {
+		v.pending = make(map[string]Challenge)
+	}
+	for nonce, c := range v.pending {
+		if now.Unix() > c.Exp {
+			delete(v.pending, nonce)
+		}
+	}
+	v.pending[challenge.Nonce] = challenge
+	return challenge
+}
+
+// Verify checks a response to one of the verifier's challenges. The
+// challenge is consumed by the first valid response only, so that an
+// invalid response cannot burn a challenge issued to someone else.
+func (v *Verifier) Verify(jws saotypes.GeneralJWS) (Session, error) {
+	if v.Resolver == nil {
+		return Session{}, xerrors.New("resolver is missing.")
+	}
+	var payload saotypes.Payload
+	if err := util.Base64urlToJSON(jws.Payload, &payload); err != nil {
+		return Session{}, xerrors.New("parse payload failed: " + err.Error())
+	}
+	challenge, err := v.lookup(payload.Nonce)
+	if err != nil {
+		return Session{}, err
+	}
+	if !equalPaths(payload.Paths, challenge.Paths) {
+		return Session{}, xerrors.New("invalid authentication response: paths do not match the challenge")
+	}
+
+	dm := did.NewDidManager(nil, v.Resolver)
+	dm.Clock = v.Clock
+	dm.ClockSkew = v.ClockSkew
+	payload, err = dm.VerifyAuthentication(jws, did.AuthVerifyOptions{Aud: challenge.Aud, Nonce: challenge.Nonce})
+	if err != nil {
+		return Session{}, err
+	}
+	if !v.consume(challenge.Nonce) {
+		return Session{}, xerrors.New("invalid authentication response: unknown or used challenge")
+	}
+	return Session{
+		Did:       payload.Did,
+		Aud:       payload.Aud,
+		Paths:     payload.Paths,
+		ExpiresAt: time.Unix(payload.Exp, 0),
+	}, nil
+}
+
+// Authorize returns a *scope.OutOfScopeError unless the session paths
+// grant access to resource.
+func (s Session) Authorize(resource string) error {
+	return scope.Authorize(s.Paths, resource)
+}
+
+func (v *Verifier) lookup(nonce string) (Challenge, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	challenge, ok := v.pending[nonce]
+	if !ok {
+		return Challenge{}, xerrors.New("invalid authentication response: unknown or used challenge")
+	}
+	if v.now().Unix() > challenge.Exp {
+		delete(v.pending, nonce)
+		return Challenge{}, xerrors.New("invalid authentication response: challenge expired")
+	}
+	return challenge, nil
+}
+
+// consume removes the challenge, it reports false when a concurrent
+// response already did.
+func (v *Verifier) consume(nonce string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.pending[nonce]; !ok {
+		return false
+	}
+	delete(v.pending, nonce)
+	return true
+}
+
+func (v *Verifier) now() time.Time {
+	if v.Clock == nil {
+		return time.Now()
+	}
+	return v.Clock.Now()
+}
+
+func equalPaths(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,238 @@
+// Package dagjose encodes signed and encrypted JOSE objects as IPLD blocks
+// with the dag-jose codec, https://ipld.io/specs/codecs/dag-jose/spec/.
+// The wire format is the one of js-dag-jose used by Ceramic.
+package dagjose
+
+import (
+	"github.com/SaoNetwork/sao-did/types"
+	"github.com/dvsekhvalnov/jose2go/base64url"
+	"github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	mc "github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-multihash"
+	"golang.org/x/xerrors"
+)
+
+const DagJoseCodec = uint64(mc.DagJose)
+
+// EncodeDagJWS encodes the JWS as a dag-jose block. The payload of the JWS
+// must be a base64url encoded CID, the link is derived from it and not
+// stored.
+func EncodeDagJWS(jws types.DagJWS) ([]byte, error) {
+	payload, err := base64url.Decode(jws.Payload)
+	if err != nil {
+		return nil, xerrors.Errorf("decode payload failed: %v", err)
+	}
+	if _, err = cid.Cast(payload); err != nil {
+		return nil, xerrors.Errorf("payload should be a CID: %v", err)
+	}
+	if len(jws.Signatures) == 0 {
+		return nil, xerrors.New("jws has no signature")
+	}
+	var signatures []any
+	for _, s := range jws.Signatures {
+		signature := map[string]any{}
+		if s.Protected != "" {
+			if signature["protected"], err = base64url.Decode(s.Protected); err != nil {
+				return nil, xerrors.Errorf("decode protected header failed: %v", err)
+			}
+		}
+		if len(s.Header) != 0 {
+			signature["header"] = s.Header
+		}
+		if signature["signature"], err = base64url.Decode(s.Signature); err != nil {
+			return nil, xerrors.Errorf("decode signature failed: %v", err)
+		}
+		signatures = append(signatures, signature)
+	}
+	return cbornode.DumpObject(map[string]any{
+		"payload":    payload,
+		"signatures": signatures,
+	})
+}
+
+// DecodeDagJWS decodes a dag-jose JWS block and sets its link.
+func DecodeDagJWS(data []byte) (types.DagJWS, error) {
+	var block map[string]any
+	if err := cbornode.DecodeInto(data, &block); err != nil {
+		return types.DagJWS{}, xerrors.Errorf("decode dag-jose block failed: %v", err)
+	}
+	payload, ok := block["payload"].([]byte)
+	if !ok {
+		return types.DagJWS{}, xerrors.New("dag-jose jws has no payload")
+	}
+	link, err := cid.Cast(payload)
+	if err != nil {
+		return types.DagJWS{}, xerrors.Errorf("payload should be a CID: %v", err)
+	}
+	signatures, ok := block["signatures"].([]any)
+	if !ok || len(signatures) == 0 {
+		return types.DagJWS{}, xerrors.New("dag-jose jws has no signatures")
+	}
+	jws := types.DagJWS{Payload: base64url.Encode(payload), Link: &link}
+	for _, s := range signatures {
+		fields, ok := s.(map[string]any)
+		if !ok {
+			return types.DagJWS{}, xerrors.New("invalid dag-jose signature")
+		}
+		var signature types.JwsSignature
+		if signature.Protected, err = optionalBytes(fields, "protected"); err != nil {
+			return types.DagJWS{}, err
+		}
+		if signature.Signature, err = optionalBytes(fields, "signature"); err != nil {
+			return types.DagJWS{}, err
+		}
+		if signature.Signature == "" {
+			return types.DagJWS{}, xerrors.New("dag-jose signature is missing")
+		}
+		if signature.Header, err = optionalMap(fields, "header"); err != nil {
+			return types.DagJWS{}, err
+		}
+		jws.Signatures = append(jws.Signatures, signature)
+	}
+	return jws, nil
+}
+
+// EncodeDagJWE encodes the JWE as a dag-jose block.
+func EncodeDagJWE(jwe types.DagJWE) ([]byte, error) {
+	block := map[string]any{}
+	for name, value := range map[string]string{
+		"protected":  jwe.Protected,
+		"iv":         jwe.Iv,
+		"aad":        jwe.Aad,
+		"ciphertext": jwe.Ciphertext,
+		"tag":        jwe.Tag,
+	} {
+		if value == "" {
+			continue
+		}
+		bytes, err := base64url.Decode(value)
+		if err != nil {
+			return nil, xerrors.Errorf("decode %s failed: %v", name, err)
+		}
+		block[name] = bytes
+	}
+	if block["ciphertext"] == nil || block["iv"] == nil || block["tag"] == nil || block["protected"] == nil {
+		return nil, xerrors.New("jwe should have protected, iv, ciphertext and tag")
+	}
+	if len(jwe.Unprotected) != 0 {
+		block["unprotected"] = jwe.Unprotected
+	}
+	if len(jwe.Recipients) != 0 {
+		var recipients []any
+		for _, r := range jwe.Recipients {
+			recipient := map[string]any{}
+			if len(r.Header) != 0 {
+				recipient["header"] = r.Header
+			}
+			if r.EncryptedKey != "" {
+				key, err := base64url.Decode(r.EncryptedKey)
+				if err != nil {
+					return nil, xerrors.Errorf("decode encrypted_key failed: %v", err)
+				}
+				recipient["encrypted_key"] = key
+			}
+			recipients = append(recipients, recipient)
+		}
+		block["recipients"] = recipients
+	}
+	return cbornode.DumpObject(block)
+}
+
+// DecodeDagJWE decodes a dag-jose JWE block.
+func DecodeDagJWE(data []byte) (types.DagJWE, error) {
+	var block map[string]any
+	if err := cbornode.DecodeInto(data, &block); err != nil {
+		return types.DagJWE{}, xerrors.Errorf("decode dag-jose block failed: %v", err)
+	}
+	var jwe types.DagJWE
+	var err error
+	for name, field := range map[string]*string{
+		"protected":  &jwe.Protected,
+		"iv":         &jwe.Iv,
+		"aad":        &jwe.Aad,
+		"ciphertext": &jwe.Ciphertext,
+		"tag":        &jwe.Tag,
+	} {
+		if *field, err = optionalBytes(block, name); err != nil {
+			return types.DagJWE{}, err
+		}
+	}
+	if jwe.Ciphertext == "" || jwe.Iv == "" || jwe.Tag == "" || jwe.Protected == "" {
+		return types.DagJWE{}, xerrors.New("dag-jose jwe should have protected, iv, ciphertext and tag")
+	}
+	if jwe.Unprotected, err = optionalMap(block, "unprotected"); err != nil {
+		return types.DagJWE{}, err
+	}
+	if recipients, ok := block["recipients"].([]any); ok {
+		for _, r := range recipients {
+			fields, ok := r.(map[string]any)
+			if !ok {
+				return types.DagJWE{}, xerrors.New("invalid dag-jose recipient")
+			}
+			var recipient types.JweRecipient
+			if recipient.EncryptedKey, err = optionalBytes(fields, "encrypted_key"); err != nil {
+				return types.DagJWE{}, err
+			}
+			if recipient.Header, err = optionalMap(fields, "header"); err != nil {
+				return types.DagJWE{}, err
+			}
+			jwe.Recipients = append(jwe.Recipients, recipient)
+		}
+	}
+	return jwe, nil
+}
+
+// IsDagJWS reports whether the dag-jose block is a JWS, otherwise it is a JWE.
+func IsDagJWS(data []byte) (bool, error) {
+	var block map[string]any
+	if err := cbornode.DecodeInto(data, &block); err != nil {
+		return false, xerrors.Errorf("decode dag-jose block failed: %v", err)
+	}
+	_, ok := block["payload"]
+	return ok, nil
+}
+
+// Cid returns the CIDv1 of the dag-jose block, hashed with SHA2-256.
+func Cid(block []byte) (cid.Cid, error) {
+	return cid.Prefix{
+		Version:  1,
+		Codec:    DagJoseCodec,
+		MhType:   multihash.SHA2_256,
+		MhLength: -1,
+	}.Sum(block)
+}
+
+// WrapDagJWS encodes the JWS and returns its block and CID.
+func WrapDagJWS(jws types.DagJWS) (cid.Cid, []byte, error) {
+	block, err := EncodeDagJWS(jws)
+	if err != nil {
+		return cid.Undef, nil, err
+	}
+	c, err := Cid(block)
+	return c, block, err
+}
+
+func optionalBytes(fields map[string]any, name string) (string, error) {
+	value, ok := fields[name]
+	if !ok {
+		return "", nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return "", xerrors.Errorf("dag-jose %s should be bytes", name)
+	}
+	return base64url.Encode(bytes), nil
+}
+
+func optionalMap(fields map[string]any, name string) (map[string]any, error) {
+	value, ok := fields[name]
+	if !ok {
+		return nil, nil
+	}
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, xerrors.Errorf("dag-jose %s should be a map", name)
+	}
+	return m, nil
+}
@@ -1,19 +1,18 @@
 package did
 
 import (
-	"strings"
+	"encoding/json"
 	"time"
 
 	"github.com/SaoNetwork/sao-did/key"
 	"github.com/SaoNetwork/sao-did/parser"
+	"github.com/SaoNetwork/sao-did/scope"
 	"github.com/SaoNetwork/sao-did/sid"
 	"github.com/SaoNetwork/sao-did/types"
 	"github.com/SaoNetwork/sao-did/util"
-	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	"github.com/dvsekhvalnov/jose2go/base64url"
+	"github.com/ipfs/go-cid"
 	cbornode "github.com/ipfs/go-ipld-cbor"
-	"github.com/mr-tron/base58"
-	"github.com/multiformats/go-multibase"
 	"github.com/multiformats/go-multihash"
 	"github.com/thanhpk/randstr"
 	"golang.org/x/xerrors"
@@ -23,6 +22,11 @@ type DidManager struct {
 	Id       string
 	Provider types.DidProvider
 	Resolver types.DidResolver
+	// Clock defaults to the system clock.
+	Clock types.Clock
+	// ClockSkew is the allowed difference between the local clock and the
+	// clocks of signers, applied to exp, updated and nextUpdate checks.
+	ClockSkew time.Duration
 }
 
 func NewDidManagerWithDid(didString string, qf sid.QueryFunc) (*DidManager, error) {
@@ -71,43 +75,226 @@ func (d *DidManager) Authenticate(paths []string, aud string) (string, error) {
 		return "", err
 	}
 
+	payload, err := d.VerifyAuthentication(jws, AuthVerifyOptions{Aud: aud, Nonce: nonce})
+	if err != nil {
+		return "", err
+	}
+	d.Id = payload.Did
+	return payload.Did, nil
+}
+
+// AuthVerifyOptions are what the verifier of an authentication response
+// expects.
+type AuthVerifyOptions struct {
+	Aud string
+	// Nonce is the nonce sent to the client, any nonce is accepted when
+	// empty. Client chosen nonces are only safe with a ReplayCache.
+	Nonce string
+	// ReplayCache rejects nonce and jti values used before by the same DID.
+	ReplayCache types.ReplayCache
+	// Resource is the path being accessed, when set it must be in the
+	// authenticated paths or a *scope.OutOfScopeError is returned.
+	Resource string
+}
+
+// VerifyAuthentication verifies an authentication response created by
+// DidProvider.Authenticate on the receiving side and returns its payload.
+func (d *DidManager) VerifyAuthentication(jws types.GeneralJWS, options AuthVerifyOptions) (types.Payload, error) {
+	if d.Resolver == nil {
+		return types.Payload{}, xerrors.New("resolver is missing.")
+	}
 	var payload types.Payload
-	err = util.Base64urlToJSON(jws.Payload, &payload)
+	err := util.Base64urlToJSON(jws.Payload, &payload)
 	if err != nil {
-		return "", xerrors.New("parse payload failed: " + err.Error())
+		return types.Payload{}, xerrors.New("parse payload failed: " + err.Error())
 	}
 
-	kid, err := d.VerifyJWS(jws)
+	kid, capability, err := d.verifyJWS(jws, true)
 	if err != nil {
-		return "", xerrors.New("verifyJWS failed: " + err.Error())
+		return types.Payload{}, xerrors.New("verifyJWS failed: " + err.Error())
 	}
-	if !strings.Contains(kid, payload.Did) {
-		return "", xerrors.New("Invalid authencation response, kid mismatch")
+	if capability != nil && !scope.Subset(payload.Paths, capability.Paths) {
+		return types.Payload{}, xerrors.New("Invalid authencation response, paths exceed the session capability")
 	}
-	if payload.Nonce != nonce {
-		return "", xerrors.New("Invalid authencation response, wrong nonce")
+	// kid is the parent key for a session signature, whose DID is the
+	// capability issuer
+	if signer, err := util.KidToDid(kid); err != nil || payload.Did == "" || signer != payload.Did {
+		return types.Payload{}, xerrors.New("Invalid authencation response, kid mismatch")
 	}
-	if payload.Aud != aud {
-		return "", xerrors.New("Invalid authencation response, wrong aud")
+	if options.Nonce != "" && payload.Nonce != options.Nonce {
+		return types.Payload{}, xerrors.New("Invalid authencation response, wrong nonce")
 	}
-	if payload.Exp < time.Now().Unix() {
-		return "", xerrors.New("Invalid authencation response, expired")
+	if payload.Aud != options.Aud {
+		return types.Payload{}, xerrors.New("Invalid authencation response, wrong aud")
 	}
-	d.Id = payload.Did
-	return payload.Did, nil
+	if time.Unix(payload.Exp, 0).Add(d.ClockSkew).Before(d.now()) {
+		return types.Payload{}, xerrors.New("Invalid authencation response, expired")
+	}
+	if options.ReplayCache != nil && payload.Nonce == "" {
+		return types.Payload{}, xerrors.New("Invalid authencation response, missing nonce")
+	}
+	if options.Resource != "" {
+		if err = scope.Authorize(payload.Paths, options.Resource); err != nil {
+			return types.Payload{}, err
+		}
+	}
+	// the nonce is used up last, a response rejected by any other check
+	// can still be retried
+	if options.ReplayCache != nil {
+		expiry := time.Unix(payload.Exp, 0).Add(d.ClockSkew)
+		fresh, err := options.ReplayCache.Use("nonce:"+payload.Did+":"+payload.Nonce, expiry)
+		if err != nil {
+			return types.Payload{}, err
+		}
+		if fresh && payload.Jti != "" {
+			fresh, err = options.ReplayCache.Use("jti:"+payload.Did+":"+payload.Jti, expiry)
+			if err != nil {
+				return types.Payload{}, err
+			}
+		}
+		if !fresh {
+			return types.Payload{}, xerrors.New("Invalid authencation response, replayed")
+		}
+	}
+	return payload, nil
+}
+
+func (d *DidManager) now() time.Time {
+	if d.Clock == nil {
+		return time.Now()
+	}
+	return d.Clock.Now()
 }
 
-func (d *DidManager) CreateJWS(payload []byte) (types.DagJWS, error) {
-	generalJws, err := d.Provider.CreateJWS(payload)
+func (d *DidManager) CreateJWS(payload []byte, options ...types.CreateJWSOptions) (types.DagJWS, error) {
+	generalJws, err := d.Provider.CreateJWS(payload, options...)
 	return generalJws.ToDagJWS(), err
 }
 
+// VerifyDetachedJWS verifies a JWS whose payload was transported separately.
+func (d *DidManager) VerifyDetachedJWS(jws types.GeneralJWS, payload []byte) (string, error) {
+	if !jws.IsDetached() {
+		return "", xerrors.New("invalid jws: payload is not detached")
+	}
+	attached, err := jws.Attach(payload)
+	if err != nil {
+		return "", xerrors.Errorf("invalid jws: %v", err)
+	}
+	return d.VerifyJWS(attached)
+}
+
+// VerifyJWS verifies the first signature of the JWS and returns its kid.
+// A JWS signed by a session key is rejected: its capability is limited to
+// paths, which only VerifySessionJWS callers can check.
 func (d *DidManager) VerifyJWS(jws types.GeneralJWS) (string, error) {
+	kid, _, err := d.verifyJWS(jws, false)
+	return kid, err
+}
+
+// VerifySessionJWS is VerifyJWS also accepting a JWS signed by a session
+// key when its capability is valid. The returned kid is then the parent
+// key that signed the capability, which is returned too: the caller must
+// check that the capability paths cover what the JWS is used for.
+func (d *DidManager) VerifySessionJWS(jws types.GeneralJWS) (string, *types.Capability, error) {
+	return d.verifyJWS(jws, true)
+}
+
+func (d *DidManager) verifyJWS(jws types.GeneralJWS, allowSession bool) (string, *types.Capability, error) {
+	if len(jws.Signatures) == 0 {
+		return "", nil, xerrors.New("invalid jws: no signature")
+	}
+	if jws.IsDetached() {
+		return "", nil, xerrors.New("invalid jws: payload is missing, a detached jws needs VerifyDetachedJWS")
+	}
 	kid, err := jws.Signatures[0].GetKid()
 	if err != nil {
-		return "", xerrors.Errorf("invalid jws: %v", err)
+		return "", nil, xerrors.Errorf("invalid jws: %v", err)
+	}
+	header, err := jws.Signatures[0].GetProtectedHeader()
+	if err != nil {
+		return "", nil, xerrors.Errorf("invalid jws: %v", err)
+	}
+	if err = checkCrit(jws.Signatures[0].Protected, header); err != nil {
+		return "", nil, xerrors.Errorf("invalid jws: %v", err)
+	}
+	var fields map[string]any
+	if err = util.Base64urlToJSON(jws.Signatures[0].Protected, &fields); err != nil {
+		return "", nil, xerrors.Errorf("invalid jws: %v", err)
+	}
+	if capJws, ok := fields[types.CapabilityHeader]; ok {
+		if !allowSession {
+			return "", nil, xerrors.New("invalid jws: session key signatures are not accepted here")
+		}
+		return d.verifySessionJWS(jws, kid, capJws)
+	}
+	kid, err = d.verifyKeyJWS(jws, kid)
+	return kid, nil, err
+}
+
+// verifySessionJWS verifies a JWS signed by a session key: the capability
+// must be signed by its issuer, name the signing did:key as audience and
+// be valid now.
+func (d *DidManager) verifySessionJWS(jws types.GeneralJWS, kid string, capJws any) (string, *types.Capability, error) {
+	compact, ok := capJws.(string)
+	if !ok {
+		return "", nil, xerrors.New("invalid jws: cap must be a compact jws")
+	}
+	capability, err := types.ParseCompact(compact)
+	if err != nil {
+		return "", nil, xerrors.Errorf("invalid capability: %v", err)
+	}
+	var c types.Capability
+	if err = util.Base64urlToJSON(capability.Payload, &c); err != nil {
+		return "", nil, xerrors.Errorf("invalid capability: %v", err)
+	}
+	var capHeader map[string]any
+	if err = util.Base64urlToJSON(capability.Signatures[0].Protected, &capHeader); err != nil {
+		return "", nil, xerrors.Errorf("invalid capability: %v", err)
+	}
+	if _, nested := capHeader[types.CapabilityHeader]; nested {
+		return "", nil, xerrors.New("invalid capability: nested capabilities are not supported")
+	}
+	if err = scope.Validate(c.Paths); err != nil {
+		return "", nil, xerrors.Errorf("invalid capability: %v", err)
+	}
+	parentKid, err := d.VerifyJWS(capability)
+	if err != nil {
+		return "", nil, xerrors.Errorf("invalid capability: %v", err)
+	}
+	parent, err := util.KidToDid(parentKid)
+	if err != nil {
+		return "", nil, err
+	}
+	if parent != c.Iss {
+		return "", nil, xerrors.New("invalid capability: not signed by its issuer")
+	}
+
+	sessionDid, err := util.KidToDid(kid)
+	if err != nil {
+		return "", nil, err
+	}
+	if sessionDid != c.Aud {
+		return "", nil, xerrors.New("invalid_jws: signature is not authored by the capability session key")
+	}
+	now := d.now()
+	if now.Add(-d.ClockSkew).Unix() >= c.Exp {
+		return "", nil, xerrors.New("invalid capability: expired")
+	}
+	if now.Add(d.ClockSkew).Unix() < c.Iat {
+		return "", nil, xerrors.New("invalid capability: issued in the future")
+	}
+
+	// session keys are always did:key, whatever the manager resolves
+	session := NewDidManager(nil, key.NewKeyResolver())
+	session.Clock = d.Clock
+	session.ClockSkew = d.ClockSkew
+	if _, err = session.verifyKeyJWS(jws, kid); err != nil {
+		return "", nil, err
 	}
+	return parentKid, &c, nil
+}
 
+func (d *DidManager) verifyKeyJWS(jws types.GeneralJWS, kid string) (string, error) {
 	if d.Id != "" {
 		didInSig, err := util.KidToDid(kid)
 		if err != nil {
@@ -127,7 +314,7 @@ func (d *DidManager) VerifyJWS(jws types.GeneralJWS) (string, error) {
 		if err != nil {
 			return "", xerrors.New("nextUpdate should be RFC3339 format" + err.Error())
 		}
-		if time.Now().After(revocationTime) {
+		if d.now().Add(-d.ClockSkew).After(revocationTime) {
 			// Do not allow using a key _after_ it is being revoked
 			return "", xerrors.New("invalid_jws: signature authored with a revoked DID version: " + kid)
 		}
@@ -139,24 +326,134 @@ func (d *DidManager) VerifyJWS(jws types.GeneralJWS) (string, error) {
 		if err != nil {
 			return "", xerrors.New("Updated should be RFC3339 format" + err.Error())
 		}
-		if time.Now().Before(updatedTime) {
+		if d.now().Add(d.ClockSkew).Before(updatedTime) {
 			return "", xerrors.New("invalid_jws: signature authored before creation of DID version: ${kid}")
 		}
 	}
+	if didResolutionResult.DidDocumentMetadata.Deactivated {
+		// Like the revocation check above, this compares the verification
+		// time, not a signing time: a time in the payload is chosen by the
+		// signer and could be backdated. Signatures of a deactivated DID are
+		// only accepted until its deactivation time.
+		deactivationTime, err := time.Parse(time.RFC3339, didResolutionResult.DidDocumentMetadata.DeactivationTime)
+		if err != nil {
+			return "", xerrors.New("invalid_jws: DID is deactivated: " + kid)
+		}
+		if d.now().Add(-d.ClockSkew).After(deactivationTime) {
+			return "", xerrors.New("invalid_jws: DID was deactivated at " + deactivationTime.Format(time.RFC3339) + ": " + kid)
+		}
+	}
 	publicKeys := didResolutionResult.DidDocument.VerificationMethod
 	// verifyJWS will throw an error if the signature is invalid
-	err = verifyJWS(jws, publicKeys)
+	err := verifySignature(jws, kid, publicKeys)
 	if err != nil {
 		return kid, xerrors.New("verify JWS failed: " + err.Error())
 	}
-	//var payload Payload
-	//base64urlToJSON(jws.Payload, payload);
-	// If an error is thrown it means that the payload is a CID.
+	return kid, nil
+}
 
+// VerifyDagJWS verifies the signature of a DagJWS, checks that its payload
+// is the CID of the linked block and decodes the block into out, when out
+// is not nil. It returns the kid of the signature.
+func (d *DidManager) VerifyDagJWS(result types.DagJWSResult, out interface{}) (string, error) {
+	kid, err := d.VerifyJWS(types.GeneralJWS{Payload: result.Jws.Payload, Signatures: result.Jws.Signatures})
+	if err != nil {
+		return "", err
+	}
+	payload, err := base64url.Decode(result.Jws.Payload)
+	if err != nil {
+		return kid, xerrors.Errorf("invalid dag jws: decode payload failed: %v", err)
+	}
+	payloadCid, err := cid.Cast(payload)
+	if err != nil {
+		// DagJWS created before dag-jose payloads signed the base64url CID
+		// string, base64url encoded again
+		legacy, legacyErr := base64url.Decode(string(payload))
+		if legacyErr != nil {
+			return kid, xerrors.Errorf("invalid dag jws: payload is not a CID: %v", err)
+		}
+		if payloadCid, err = cid.Cast(legacy); err != nil {
+			return kid, xerrors.Errorf("invalid dag jws: payload is not a CID: %v", err)
+		}
+	}
+	if result.Jws.Link != nil && !result.Jws.Link.Equals(payloadCid) {
+		return kid, xerrors.New("invalid dag jws: link does not match the payload CID")
+	}
+	blockCid, err := payloadCid.Prefix().Sum(result.LinkedBlock)
+	if err != nil {
+		return kid, xerrors.Errorf("invalid dag jws: hash linked block failed: %v", err)
+	}
+	if !blockCid.Equals(payloadCid) {
+		return kid, xerrors.New("invalid dag jws: linked block does not match the payload CID")
+	}
+	if out == nil {
+		return kid, nil
+	}
+	switch payloadCid.Type() {
+	case cid.DagCBOR:
+		err = cbornode.DecodeInto(result.LinkedBlock, out)
+	case cid.DagJSON:
+		err = json.Unmarshal(result.LinkedBlock, out)
+	default:
+		err = xerrors.Errorf("unsupported linked block codec %x", payloadCid.Type())
+	}
+	if err != nil {
+		return kid, xerrors.Errorf("decode linked block failed: %v", err)
+	}
 	return kid, nil
 }
 
-func verifyJWS(jws types.GeneralJWS, pks []types.VerificationMethod) error {
+// understoodCrit are the critical header parameters this package processes.
+var understoodCrit = map[string]bool{"b64": true}
+
+func checkCrit(protected string, header types.JWTHeader) error {
+	if header.Crit == nil {
+		return nil
+	}
+	if len(header.Crit) == 0 {
+		return xerrors.New("crit must not be empty")
+	}
+	var fields map[string]any
+	if err := util.Base64urlToJSON(protected, &fields); err != nil {
+		return err
+	}
+	for _, c := range header.Crit {
+		if !understoodCrit[c] {
+			return xerrors.Errorf("unsupported critical header parameter %s", c)
+		}
+		if _, ok := fields[c]; !ok {
+			return xerrors.Errorf("critical header parameter %s is missing", c)
+		}
+	}
+	if header.B64 != nil && !critContains(header.Crit, "b64") {
+		return xerrors.New("b64 must be listed in crit")
+	}
+	return nil
+}
+
+func critContains(crit []string, name string) bool {
+	for _, c := range crit {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// verifySignature verifies the signature with the verification method
+// named by kid only, with the alg of its key type.
+func verifySignature(jws types.GeneralJWS, kid string, pks []types.VerificationMethod) error {
+	vm, err := findVerificationMethod(kid, pks)
+	if err != nil {
+		return err
+	}
+	header, err := jws.Signatures[0].GetProtectedHeader()
+	if err != nil {
+		return err
+	}
+	if alg := key.JWSAlgorithm(vm.Type); alg == "" || header.Alg != alg {
+		return xerrors.Errorf("alg %s does not match the %s verification method", header.Alg, vm.Type)
+	}
 	data := jws.Signatures[0].Protected + "." + jws.Payload
 
 	rawSig, err := base64url.Decode(jws.Signatures[0].Signature)
@@ -164,43 +461,81 @@ func verifyJWS(jws types.GeneralJWS, pks []types.VerificationMethod) error {
 		return xerrors.New("raw signature decode failed:" + err.Error())
 	}
 
-	var rawPk []byte
-	for _, pk := range pks {
-		//pk.Type
-		if pk.PublicKeyBase58 != "" {
-			rawPk, err = base58.Decode(pk.PublicKeyBase58)
-		} else if pk.PublicKeyMultibase != "" {
-			_, rawPk, err = multibase.Decode(pk.PublicKeyMultibase)
-		}
-		if err != nil {
-			return xerrors.Errorf("decode pubKey failed: %v", err)
-		}
-
-		if rawPk != nil {
-			pubkey := secp256k1.PubKey{Key: rawPk}
+	return key.VerifySignature([]byte(data), rawSig, []types.VerificationMethod{vm})
+}
 
-			if pubkey.VerifySignature([]byte(data), rawSig) {
-				return nil
-			}
+// findVerificationMethod returns the verification method whose id is kid,
+// ignoring the DID query of kid such as a versionId.
+func findVerificationMethod(kid string, pks []types.VerificationMethod) (types.VerificationMethod, error) {
+	parsed, err := parser.Parse(kid)
+	if err != nil {
+		return types.VerificationMethod{}, err
+	}
+	if parsed.Fragment == "" {
+		return types.VerificationMethod{}, xerrors.New("kid has no fragment: " + kid)
+	}
+	did := "did:" + parsed.Method + ":" + parsed.ID
+	for _, vm := range pks {
+		if vm.Id == did+"#"+parsed.Fragment || vm.Id == "#"+parsed.Fragment {
+			return vm, nil
 		}
 	}
-	return xerrors.New("invalid_signature: Signature invalid for JWT")
+	return types.VerificationMethod{}, xerrors.New("no verification method " + kid)
 }
 
+// CreateDagJWS encodes payload as the linked block and signs its CID. The
+// JWS payload is the CID bytes as dag-jose specifies; earlier versions of
+// this package signed the base64url CID string instead, VerifyDagJWS
+// accepts both.
 func (d *DidManager) CreateDagJWS(
 	payload interface{},
-	// options: CreateJWSOptions = {}
+	options ...types.CreateDagJWSOptions,
 ) (types.DagJWSResult, error) {
-	node, err := cbornode.WrapObject(payload, multihash.SHA2_256, multihash.DefaultLengths[multihash.SHA2_256])
+	var opts types.CreateDagJWSOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	prefix := cid.Prefix{
+		Version:  1,
+		Codec:    opts.Codec,
+		MhType:   opts.HashFunction,
+		MhLength: -1,
+	}
+	if prefix.Codec == 0 {
+		prefix.Codec = cid.DagCBOR
+	}
+	if prefix.MhType == 0 {
+		prefix.MhType = multihash.SHA2_256
+	}
+
+	var linkedBlock []byte
+	var err error
+	switch prefix.Codec {
+	case cid.DagCBOR:
+		linkedBlock, err = cbornode.DumpObject(payload)
+	case cid.DagJSON:
+		linkedBlock, err = util.EncodeDagJSON(payload)
+	default:
+		err = xerrors.Errorf("unsupported linked block codec %x", prefix.Codec)
+	}
+	if err != nil {
+		return types.DagJWSResult{}, err
+	}
+	cid, err := prefix.Sum(linkedBlock)
 	if err != nil {
 		return types.DagJWSResult{}, err
 	}
-	cid := node.Cid()
-	linkedBlock := node.RawData()
-	payloadCid := base64url.Encode(cid.Bytes())
 
-	//Object.assign(options, { linkedBlock: encodeBase64(linkedBlock) })
-	jws, err := d.CreateJWS([]byte(payloadCid)) //, options)
+	jwsOptions := types.CreateJWSOptions{ProtectedHeader: map[string]any{}}
+	for k, v := range opts.ProtectedHeader {
+		jwsOptions.ProtectedHeader[k] = v
+	}
+	if opts.EmbedLinkedBlock {
+		jwsOptions.ProtectedHeader["linkedBlock"] = base64url.Encode(linkedBlock)
+	}
+	// the provider base64url encodes the payload, so the JWS payload is the
+	// base64url CID as in dag-jose
+	jws, err := d.CreateJWS(cid.Bytes(), jwsOptions)
 	if err != nil {
 		return types.DagJWSResult{}, err
 	}
@@ -0,0 +1,140 @@
+// Package hd derives provider keys from BIP39 mnemonics: secp256k1 keys
+// with BIP32 and Ed25519 keys with SLIP-0010, along configurable paths.
+package hd
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"strconv"
+	"strings"
+
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/go-bip39"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// DefaultSecp256k1Path is the Cosmos BIP44 path.
+	DefaultSecp256k1Path = "m/44'/118'/0'/0/0"
+	// DefaultEd25519Path is the Cosmos BIP44 path with every level hardened
+	// as SLIP-0010 requires for Ed25519.
+	DefaultEd25519Path = "m/44'/118'/0'/0'/0'"
+
+	hardenedOffset = 0x80000000
+)
+
+// NewMnemonic generates a mnemonic with bits of entropy: 128 for 12 words
+// up to 256 for 24 words.
+func NewMnemonic(bits int) (string, error) {
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// ValidateMnemonic checks the words and the checksum of mnemonic.
+func ValidateMnemonic(mnemonic string) error {
+	if _, err := bip39.MnemonicToByteArray(mnemonic); err != nil {
+		return xerrors.Errorf("invalid mnemonic: %v", err)
+	}
+	return nil
+}
+
+// Seed validates mnemonic and returns its BIP39 seed.
+func Seed(mnemonic string, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	return bip39.NewSeed(mnemonic, passphrase), nil
+}
+
+// DeriveSecp256k1 derives the BIP32 secp256k1 private key at path.
+func DeriveSecp256k1(seed []byte, path string) ([]byte, error) {
+	master, chainCode := hd.ComputeMastersFromSeed(seed)
+	if strings.TrimSpace(path) == "m" {
+		return master[:], nil
+	}
+	return hd.DerivePrivateKeyForPath(master, chainCode, path)
+}
+
+// DeriveEd25519 derives the SLIP-0010 Ed25519 seed at path, Ed25519 only
+// supports hardened levels.
+func DeriveEd25519(seed []byte, path string) ([]byte, error) {
+	indexes, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	key, chainCode := hmacSha512([]byte("ed25519 seed"), seed)
+	for _, index := range indexes {
+		if index < hardenedOffset {
+			return nil, xerrors.Errorf("invalid ed25519 path %s: levels must be hardened", path)
+		}
+		var indexBytes [4]byte
+		binary.BigEndian.PutUint32(indexBytes[:], index)
+		data := make([]byte, 0, 37)
+		data = append(data, 0)
+		data = append(data, key...)
+		data = append(data, indexBytes[:]...)
+		key, chainCode = hmacSha512(chainCode, data)
+	}
+	return key, nil
+}
+
+// NewSecp256k1Provider builds the provider of the secp256k1 key at path.
+func NewSecp256k1Provider(mnemonic, passphrase, path string) (*key.Secp256k1Provider, error) {
+	seed, err := Seed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := DeriveSecp256k1(seed, path)
+	if err != nil {
+		return nil, err
+	}
+	return key.NewSecp256k1ProviderFromKey(privateKey)
+}
+
+// NewEd25519Provider builds the provider of the Ed25519 key at path.
+func NewEd25519Provider(mnemonic, passphrase, path string) (*key.Ed25519Provider, error) {
+	seed, err := Seed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := DeriveEd25519(seed, path)
+	if err != nil {
+		return nil, err
+	}
+	return key.NewEd25519Provider(privateKey)
+}
+
+func parsePath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if parts[0] != "m" {
+		return nil, xerrors.Errorf("invalid path %s: must start with m", path)
+	}
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid path %s: %v", path, err)
+		}
+		if hardened {
+			index += hardenedOffset
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+func hmacSha512(key, data []byte) ([]byte, []byte) {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
@@ -0,0 +1,44 @@
+package hd
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// test vector 1 of BIP32 and SLIP-0010
+var vectorSeed, _ = hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+func TestDeriveSecp256k1(t *testing.T) {
+	for path, expected := range map[string]string{
+		"m":      "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35",
+		"m/0'":   "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+		"m/0'/1": "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368",
+	} {
+		key, err := DeriveSecp256k1(vectorSeed, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(key) != expected {
+			t.Errorf("%s: got %x", path, key)
+		}
+	}
+}
+
+func TestDeriveEd25519(t *testing.T) {
+	for path, expected := range map[string]string{
+		"m":       "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7",
+		"m/0'":    "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3",
+		"m/0'/1'": "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2",
+	} {
+		key, err := DeriveEd25519(vectorSeed, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(key) != expected {
+			t.Errorf("%s: got %x", path, key)
+		}
+	}
+	if _, err := DeriveEd25519(vectorSeed, "m/0'/1"); err == nil {
+		t.Fatal("non hardened ed25519 level accepted")
+	}
+}
@@ -0,0 +1,123 @@
+package jwt
+
+import (
+	"encoding/json"
+
+	"golang.org/x/xerrors"
+)
+
+// registeredClaims are the claim names of RFC 7519 section 4.1.
+var registeredClaims = map[string]bool{
+	"iss": true, "sub": true, "aud": true, "exp": true, "nbf": true, "iat": true, "jti": true,
+}
+
+// Claims are the registered claims plus any private claims in Custom.
+type Claims struct {
+	Issuer    string
+	Subject   string
+	Audience  Audience
+	Expiry    int64
+	NotBefore int64
+	IssuedAt  int64
+	Id        string
+	Custom    map[string]any
+}
+
+type registered struct {
+	Issuer    string   `json:"iss,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	Expiry    int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	Id        string   `json:"jti,omitempty"`
+}
+
+func (c Claims) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]any, len(c.Custom)+len(registeredClaims))
+	for k, v := range c.Custom {
+		if registeredClaims[k] {
+			return nil, xerrors.Errorf("registered claim %s must not be a custom claim", k)
+		}
+		fields[k] = v
+	}
+	bytes, err := json.Marshal(registered{c.Issuer, c.Subject, c.Audience, c.Expiry, c.NotBefore, c.IssuedAt, c.Id})
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(bytes, &fields); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+func (c *Claims) UnmarshalJSON(data []byte) error {
+	var r registered
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for k := range registeredClaims {
+		delete(fields, k)
+	}
+	*c = Claims{r.Issuer, r.Subject, r.Audience, r.Expiry, r.NotBefore, r.IssuedAt, r.Id, fields}
+	return nil
+}
+
+// Has reports whether the claim is set.
+func (c Claims) Has(name string) bool {
+	switch name {
+	case "iss":
+		return c.Issuer != ""
+	case "sub":
+		return c.Subject != ""
+	case "aud":
+		return len(c.Audience) > 0
+	case "exp":
+		return c.Expiry != 0
+	case "nbf":
+		return c.NotBefore != 0
+	case "iat":
+		return c.IssuedAt != 0
+	case "jti":
+		return c.Id != ""
+	}
+	_, ok := c.Custom[name]
+	return ok
+}
+
+// Audience is the aud claim, serialized as a string when it has a single value.
+type Audience []string
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return xerrors.Errorf("invalid aud: %v", err)
+	}
+	*a = multiple
+	return nil
+}
+
+func (a Audience) Contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
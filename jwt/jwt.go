@@ -0,0 +1,179 @@
+// Package jwt issues and validates JSON Web Tokens signed by DIDs: the
+// issuer is the DID of a types.DidProvider and validation resolves it.
+package jwt
+
+import (
+	"encoding/json"
+	"time"
+
+	did "github.com/SaoNetwork/sao-did"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"github.com/SaoNetwork/sao-did/util"
+	"github.com/thanhpk/randstr"
+	"golang.org/x/xerrors"
+)
+
+type IssueOptions struct {
+	// Clock sets iat and exp, defaults to the system clock.
+	Clock saotypes.Clock
+	// Lifetime sets exp relative to iat unless the claims have an Expiry.
+	Lifetime time.Duration
+	// Typ is the typ header parameter, none when empty.
+	Typ string
+}
+
+// Issue signs the claims with the provider. iss is the provider DID, iat
+// and jti are set when missing.
+func Issue(provider saotypes.DidProvider, claims Claims, options IssueOptions) (string, error) {
+	if provider == nil {
+		return "", xerrors.New("provider is missing.")
+	}
+	if claims.Issuer == "" {
+		claims.Issuer = provider.Did()
+	} else if claims.Issuer != provider.Did() {
+		return "", xerrors.Errorf("issuer %s does not match provider did %s", claims.Issuer, provider.Did())
+	}
+	clock := options.Clock
+	if clock == nil {
+		clock = saotypes.SystemClock{}
+	}
+	if claims.IssuedAt == 0 {
+		claims.IssuedAt = clock.Now().Unix()
+	}
+	if claims.Expiry == 0 && options.Lifetime != 0 {
+		claims.Expiry = claims.IssuedAt + int64(options.Lifetime/time.Second)
+	}
+	if claims.Id == "" {
+		claims.Id = randstr.String(16)
+	}
+	var header map[string]any
+	if options.Typ != "" {
+		header = map[string]any{"typ": options.Typ}
+	}
+	return Sign(provider, claims, header)
+}
+
+type ValidateOptions struct {
+	// Clock defaults to the system clock.
+	Clock saotypes.Clock
+	// Leeway is the allowed clock skew for exp, nbf and iat.
+	Leeway time.Duration
+	// Audience lists the accepted audiences, the token must be intended for
+	// one of them. Tokens with an aud are rejected when it is empty.
+	Audience []string
+	// Issuers lists the accepted issuer DIDs, any when empty.
+	Issuers []string
+	// RequiredClaims must be present, registered or custom.
+	RequiredClaims []string
+	// ReplayCache rejects tokens whose jti was used before by the same
+	// issuer, tokens without jti or exp are rejected when it is set.
+	ReplayCache saotypes.ReplayCache
+}
+
+// Validate verifies the token signature against the issuer's DID document
+// and checks the registered claims.
+func Validate(token string, resolver saotypes.DidResolver, options ValidateOptions) (Claims, error) {
+	if resolver == nil {
+		return Claims{}, xerrors.New("resolver is missing.")
+	}
+	var claims Claims
+	jws, err := Decode(token, &claims)
+	if err != nil {
+		return Claims{}, err
+	}
+	dm := did.NewDidManager(nil, resolver)
+	dm.Clock = options.Clock
+	dm.ClockSkew = options.Leeway
+	kid, err := dm.VerifyJWS(jws)
+	if err != nil {
+		return Claims{}, err
+	}
+	signer, err := util.KidToDid(kid)
+	if err != nil {
+		return Claims{}, err
+	}
+	if claims.Issuer != signer {
+		return Claims{}, xerrors.Errorf("jwt issued by %s but signed by %s", claims.Issuer, signer)
+	}
+	if len(options.Issuers) > 0 && !Audience(options.Issuers).Contains(claims.Issuer) {
+		return Claims{}, xerrors.Errorf("untrusted issuer %s", claims.Issuer)
+	}
+	for _, name := range options.RequiredClaims {
+		if !claims.Has(name) {
+			return Claims{}, xerrors.Errorf("missing required claim %s", name)
+		}
+	}
+
+	clock := options.Clock
+	if clock == nil {
+		clock = saotypes.SystemClock{}
+	}
+	now := clock.Now()
+	leeway := int64(options.Leeway / time.Second)
+	if claims.Expiry != 0 && now.Unix() >= claims.Expiry+leeway {
+		return Claims{}, xerrors.New("jwt is expired")
+	}
+	if claims.NotBefore != 0 && now.Unix() < claims.NotBefore-leeway {
+		return Claims{}, xerrors.New("jwt is not valid yet")
+	}
+	if claims.IssuedAt != 0 && now.Unix() < claims.IssuedAt-leeway {
+		return Claims{}, xerrors.New("jwt is issued in the future")
+	}
+
+	if len(claims.Audience) > 0 || len(options.Audience) > 0 {
+		accepted := false
+		for _, aud := range options.Audience {
+			if claims.Audience.Contains(aud) {
+				accepted = true
+				break
+			}
+		}
+		if !accepted {
+			return Claims{}, xerrors.New("jwt is not intended for this audience")
+		}
+	}
+
+	if options.ReplayCache != nil {
+		if claims.Id == "" || claims.Expiry == 0 {
+			return Claims{}, xerrors.New("jti and exp are required for replay protection")
+		}
+		expiry := time.Unix(claims.Expiry+leeway, 0)
+		fresh, err := options.ReplayCache.Use("jti:"+claims.Issuer+":"+claims.Id, expiry)
+		if err != nil {
+			return Claims{}, err
+		}
+		if !fresh {
+			return Claims{}, xerrors.New("jwt is replayed")
+		}
+	}
+	return claims, nil
+}
+
+// Sign signs any claims value with the provider and returns the compact JWT.
+func Sign(provider saotypes.DidProvider, claims any, header map[string]any) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	jws, err := provider.CreateJWS(payload, saotypes.CreateJWSOptions{ProtectedHeader: header})
+	if err != nil {
+		return "", err
+	}
+	return jws.Compact()
+}
+
+// Decode parses a compact JWT into claims without verifying it.
+func Decode(token string, claims any) (saotypes.GeneralJWS, error) {
+	jws, err := saotypes.ParseCompact(token)
+	if err != nil {
+		return jws, err
+	}
+	payload, err := jws.DecodePayload()
+	if err != nil {
+		return jws, xerrors.Errorf("decode jwt payload failed: %v", err)
+	}
+	if err = json.Unmarshal(payload, claims); err != nil {
+		return jws, xerrors.Errorf("parse jwt claims failed: %v", err)
+	}
+	return jws, nil
+}
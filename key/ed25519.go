@@ -0,0 +1,36 @@
+package key
+
+import (
+	"fmt"
+
+	did1 "github.com/SaoNetwork/sao-did/types"
+	"github.com/mr-tron/base58"
+)
+
+const Ed25519VerificationKey2018 = "Ed25519VerificationKey2018"
+
+type Ed25519KeyResolver struct {
+}
+
+func (e Ed25519KeyResolver) ResolveKey(pubKeyBytes []byte, fingerprint string) (did1.DidDocument, error) {
+	did := fmt.Sprintf("did:key:%s", fingerprint)
+	keyId := fmt.Sprintf("%s#%s", did, fingerprint)
+	vm := did1.VerificationMethod{
+		Id:              keyId,
+		Type:            Ed25519VerificationKey2018,
+		Controller:      did,
+		PublicKeyBase58: base58.Encode(pubKeyBytes),
+	}
+	return did1.DidDocument{
+		Id: did,
+		VerificationMethod: []did1.VerificationMethod{
+			vm,
+		},
+		Authentication: []any{
+			keyId,
+		},
+		AssertionMethod: []any{
+			keyId,
+		},
+	}, nil
+}
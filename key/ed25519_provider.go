@@ -0,0 +1,75 @@
+package key
+
+import (
+	"crypto/ed25519"
+	"strings"
+
+	saodid "github.com/SaoNetwork/sao-did/types"
+	"golang.org/x/xerrors"
+)
+
+type Ed25519Provider struct {
+	did    string
+	signer saodid.Signer
+	clock  saodid.Clock
+}
+
+// NewEd25519Provider builds a provider from a 32 byte Ed25519 seed.
+func NewEd25519Provider(seed []byte) (*Ed25519Provider, error) {
+	signer, err := NewEd25519Signer(seed)
+	if err != nil {
+		return nil, err
+	}
+	return NewEd25519ProviderFromSigner(signer)
+}
+
+// NewEd25519ProviderFromSigner delegates signing to an EdDSA signer.
+func NewEd25519ProviderFromSigner(signer saodid.Signer) (*Ed25519Provider, error) {
+	if signer == nil {
+		return nil, xerrors.New("signer is missing.")
+	}
+	if signer.Algorithm() != saodid.AlgEdDSA {
+		return nil, xerrors.Errorf("signer algorithm %s is not %s", signer.Algorithm(), saodid.AlgEdDSA)
+	}
+	pubKey := signer.PublicKey()
+	if len(pubKey) != ed25519.PublicKeySize {
+		return nil, xerrors.Errorf("invalid ed25519 public key length %d", len(pubKey))
+	}
+	did, err := EncodeDidKey(KeyTypeEd25519, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Ed25519Provider{did: did, signer: signer}, nil
+}
+
+func (e *Ed25519Provider) Did() string {
+	return e.did
+}
+
+// SetClock sets the clock the expiry of authentication responses is based
+// on, the system clock by default.
+func (e *Ed25519Provider) SetClock(clock saodid.Clock) {
+	e.clock = clock
+}
+
+func (e *Ed25519Provider) Authenticate(params saodid.AuthParams) (saodid.GeneralJWS, error) {
+	payload, err := authPayload(e.did, e.clock, params)
+	if err != nil {
+		return saodid.GeneralJWS{}, err
+	}
+	return e.CreateJWS(payload)
+}
+
+func (e *Ed25519Provider) CreateJWS(
+	payload []byte,
+	options ...saodid.CreateJWSOptions,
+) (saodid.GeneralJWS, error) {
+	splits := strings.Split(e.did, ":")
+	kid := e.did + "#" + splits[2]
+	return createJWS(payload, e.signer, saodid.JWTHeader{Kid: kid, Alg: saodid.AlgEdDSA}, options...)
+}
+
+// Sign signs data with the provider key, Ed25519 hashes internally.
+func (e *Ed25519Provider) Sign(data []byte) ([]byte, error) {
+	return e.signer.Sign(data)
+}
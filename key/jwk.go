@@ -0,0 +1,103 @@
+package key
+
+import (
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/dvsekhvalnov/jose2go/base64url"
+	"golang.org/x/xerrors"
+)
+
+// JWK is a secp256k1 (kty EC) or Ed25519 (kty OKP) JSON Web Key, D is only
+// set for private keys.
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y,omitempty"`
+	D   string `json:"d,omitempty"`
+}
+
+// PublicJWK returns the JWK of a public key of keyType.
+func PublicJWK(keyType string, publicKey []byte) (JWK, error) {
+	if err := validatePublicKey(keyType, publicKey); err != nil {
+		return JWK{}, err
+	}
+	if keyType == KeyTypeEd25519 {
+		return JWK{Kty: "OKP", Crv: "Ed25519", X: base64url.Encode(publicKey)}, nil
+	}
+	pubKey, err := btcec.ParsePubKey(publicKey, btcec.S256())
+	if err != nil {
+		return JWK{}, err
+	}
+	return JWK{
+		Kty: "EC",
+		Crv: "secp256k1",
+		X:   base64url.Encode(padKey(pubKey.X.Bytes())),
+		Y:   base64url.Encode(padKey(pubKey.Y.Bytes())),
+	}, nil
+}
+
+// PrivateJWK returns the JWK of a private key of keyType.
+func PrivateJWK(keyType string, privateKey []byte) (JWK, error) {
+	publicKey, err := PublicKey(keyType, privateKey)
+	if err != nil {
+		return JWK{}, err
+	}
+	jwk, err := PublicJWK(keyType, publicKey)
+	if err != nil {
+		return JWK{}, err
+	}
+	jwk.D = base64url.Encode(privateKey)
+	return jwk, nil
+}
+
+// ParseJWK returns the key type, the public key and, when D is set, the
+// private key of jwk. The private key must match the public key.
+func ParseJWK(jwk JWK) (string, []byte, []byte, error) {
+	var keyType string
+	var publicKey []byte
+	x, err := base64url.Decode(jwk.X)
+	if err != nil {
+		return "", nil, nil, xerrors.Errorf("invalid jwk x: %v", err)
+	}
+	switch {
+	case jwk.Kty == "OKP" && jwk.Crv == "Ed25519":
+		keyType, publicKey = KeyTypeEd25519, x
+	case jwk.Kty == "EC" && jwk.Crv == "secp256k1":
+		y, err := base64url.Decode(jwk.Y)
+		if err != nil {
+			return "", nil, nil, xerrors.Errorf("invalid jwk y: %v", err)
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return "", nil, nil, xerrors.New("invalid jwk: secp256k1 coordinates must be 32 bytes")
+		}
+		curve := btcec.S256()
+		pubKey := btcec.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pubKey.X, pubKey.Y) {
+			return "", nil, nil, xerrors.New("invalid jwk: point is not on the curve")
+		}
+		keyType, publicKey = KeyTypeSecp256k1, pubKey.SerializeCompressed()
+	default:
+		return "", nil, nil, xerrors.Errorf("unsupported jwk kty %s crv %s", jwk.Kty, jwk.Crv)
+	}
+	if err = validatePublicKey(keyType, publicKey); err != nil {
+		return "", nil, nil, err
+	}
+	if jwk.D == "" {
+		return keyType, publicKey, nil, nil
+	}
+
+	privateKey, err := base64url.Decode(jwk.D)
+	if err != nil {
+		return "", nil, nil, xerrors.Errorf("invalid jwk d: %v", err)
+	}
+	derived, err := PublicKey(keyType, privateKey)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if string(derived) != string(publicKey) {
+		return "", nil, nil, xerrors.New("invalid jwk: private key does not match the public key")
+	}
+	return keyType, publicKey, privateKey, nil
+}
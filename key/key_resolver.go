@@ -27,6 +27,7 @@ type KeyResolver struct {
 func NewKeyResolver() *KeyResolver {
 	crm := make(map[uint64]KeyToDidDocument)
 	crm[uint64(codec.Secp256k1Pub)] = Secp256k1KeyResolver{}
+	crm[uint64(codec.Ed25519Pub)] = Ed25519KeyResolver{}
 	return &KeyResolver{cryptoResolverMap: crm}
 }
 
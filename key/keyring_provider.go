@@ -0,0 +1,110 @@
+package key
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	saodid "github.com/SaoNetwork/sao-did/types"
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	cryptocodec "github.com/cosmos/cosmos-sdk/crypto/codec"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"golang.org/x/xerrors"
+)
+
+// KeyringProvider signs with a secp256k1 record of a cosmos-sdk keyring,
+// the private key never leaves the keyring. It is also a types.Signer.
+type KeyringProvider struct {
+	did     string
+	uid     string
+	pubKey  []byte
+	keyring keyring.Keyring
+	clock   saodid.Clock
+}
+
+// NewKeyring opens a cosmos-sdk keyring, e.g. with the test or file
+// backend, able to decode key records.
+func NewKeyring(appName, backend, rootDir string, userInput io.Reader) (keyring.Keyring, error) {
+	registry := codectypes.NewInterfaceRegistry()
+	cryptocodec.RegisterInterfaces(registry)
+	return keyring.New(appName, backend, rootDir, userInput, codec.NewProtoCodec(registry))
+}
+
+// NewKeyringProvider uses the keyring record uid, which must be a local or
+// offline secp256k1 key. Offline keys cannot sign.
+func NewKeyringProvider(kr keyring.Keyring, uid string) (*KeyringProvider, error) {
+	if kr == nil {
+		return nil, xerrors.New("keyring is missing.")
+	}
+	record, err := kr.Key(uid)
+	if err != nil {
+		return nil, err
+	}
+	if record.GetLedger() != nil {
+		return nil, xerrors.Errorf("keyring record %s is a ledger key, ledger signatures are not supported", uid)
+	}
+	pubKey, err := record.GetPubKey()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := pubKey.(*secp256k1.PubKey); !ok {
+		return nil, xerrors.Errorf("keyring record %s is not a secp256k1 key", uid)
+	}
+	did, err := EncodeDidKey(KeyTypeSecp256k1, pubKey.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return &KeyringProvider{did: did, uid: uid, pubKey: pubKey.Bytes(), keyring: kr}, nil
+}
+
+func (k *KeyringProvider) Did() string {
+	return k.did
+}
+
+// SetClock sets the clock the expiry of authentication responses is based
+// on, the system clock by default.
+func (k *KeyringProvider) SetClock(clock saodid.Clock) {
+	k.clock = clock
+}
+
+func (k *KeyringProvider) Authenticate(params saodid.AuthParams) (saodid.GeneralJWS, error) {
+	payload, err := authPayload(k.did, k.clock, params)
+	if err != nil {
+		return saodid.GeneralJWS{}, err
+	}
+	return k.CreateJWS(payload)
+}
+
+func (k *KeyringProvider) CreateJWS(
+	payload []byte,
+	options ...saodid.CreateJWSOptions,
+) (saodid.GeneralJWS, error) {
+	splits := strings.Split(k.did, ":")
+	kid := k.did + "#" + splits[2]
+	return createJWS(payload, k, saodid.JWTHeader{Kid: kid, Alg: saodid.AlgES256K}, options...)
+}
+
+// PublicKey returns the compressed public key of the record.
+func (k *KeyringProvider) PublicKey() []byte {
+	return k.pubKey
+}
+
+func (k *KeyringProvider) Algorithm() string {
+	return saodid.AlgES256K
+}
+
+// Sign signs data with the keyring record, hashed with SHA-256 as for
+// ES256K.
+func (k *KeyringProvider) Sign(data []byte) ([]byte, error) {
+	sig, pubKey, err := k.keyring.Sign(k.uid, data)
+	if err != nil {
+		return nil, err
+	}
+	// the record may have been replaced since the provider was created
+	if !bytes.Equal(pubKey.Bytes(), k.pubKey) {
+		return nil, xerrors.Errorf("keyring record %s changed", k.uid)
+	}
+	return sig, nil
+}
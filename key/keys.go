@@ -0,0 +1,237 @@
+package key
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"strings"
+
+	saodid "github.com/SaoNetwork/sao-did/types"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/multiformats/go-multibase"
+	codec "github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-varint"
+	"golang.org/x/xerrors"
+)
+
+// Key types, private keys are raw 32 byte secp256k1 scalars or Ed25519
+// seeds and public keys are compressed secp256k1 points or raw Ed25519
+// keys.
+const (
+	KeyTypeSecp256k1 = "secp256k1"
+	KeyTypeEd25519   = "ed25519"
+)
+
+// GenerateKey returns a new random private key of keyType.
+func GenerateKey(keyType string) ([]byte, error) {
+	switch keyType {
+	case KeyTypeSecp256k1:
+		privKey, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			return nil, err
+		}
+		return padKey(privKey.D.Bytes()), nil
+	case KeyTypeEd25519:
+		seed := make([]byte, ed25519.SeedSize)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, err
+		}
+		return seed, nil
+	default:
+		return nil, unsupportedKeyType(keyType)
+	}
+}
+
+// PublicKey returns the public key of a private key of keyType.
+func PublicKey(keyType string, privateKey []byte) ([]byte, error) {
+	if err := validatePrivateKey(keyType, privateKey); err != nil {
+		return nil, err
+	}
+	switch keyType {
+	case KeyTypeSecp256k1:
+		_, pubKey := btcec.PrivKeyFromBytes(btcec.S256(), privateKey)
+		return pubKey.SerializeCompressed(), nil
+	default:
+		return ed25519.NewKeyFromSeed(privateKey).Public().(ed25519.PublicKey), nil
+	}
+}
+
+// NewSigner returns the in-memory signer of a private key of keyType.
+func NewSigner(keyType string, privateKey []byte) (saodid.Signer, error) {
+	if err := validatePrivateKey(keyType, privateKey); err != nil {
+		return nil, err
+	}
+	switch keyType {
+	case KeyTypeSecp256k1:
+		return NewSecp256k1Signer(privateKey)
+	default:
+		return NewEd25519Signer(privateKey)
+	}
+}
+
+// NewProviderFromKey returns the did:key provider of a raw private key.
+func NewProviderFromKey(keyType string, privateKey []byte) (saodid.DidProvider, error) {
+	signer, err := NewSigner(keyType, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewProvider(signer)
+}
+
+// NewProviderFromHex returns the did:key provider of a hex private key,
+// with or without 0x prefix.
+func NewProviderFromHex(keyType string, hexKey string) (saodid.DidProvider, error) {
+	privateKey, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		return nil, xerrors.Errorf("invalid hex private key: %v", err)
+	}
+	return NewProviderFromKey(keyType, privateKey)
+}
+
+// EncodeDidKey returns the did:key of a public key of keyType.
+func EncodeDidKey(keyType string, publicKey []byte) (string, error) {
+	multikey, err := EncodeMultikey(keyType, publicKey, false)
+	if err != nil {
+		return "", err
+	}
+	return "did:key:" + multikey, nil
+}
+
+// DecodeDidKey returns the key type and public key of a did:key, a DID URL
+// fragment is ignored.
+func DecodeDidKey(did string) (string, []byte, error) {
+	did = strings.SplitN(did, "#", 2)[0]
+	if !strings.HasPrefix(did, "did:key:") {
+		return "", nil, xerrors.Errorf("%s is not a did:key", did)
+	}
+	keyType, publicKey, private, err := DecodeMultikey(strings.TrimPrefix(did, "did:key:"))
+	if err != nil {
+		return "", nil, err
+	}
+	if private {
+		return "", nil, xerrors.New("did:key holds a private key")
+	}
+	return keyType, publicKey, nil
+}
+
+// EncodeMultikey encodes a public or private key of keyType as a base58btc
+// multibase Multikey, prefixed with the multicodec of the key.
+func EncodeMultikey(keyType string, key []byte, private bool) (string, error) {
+	var err error
+	if private {
+		err = validatePrivateKey(keyType, key)
+	} else {
+		err = validatePublicKey(keyType, key)
+	}
+	if err != nil {
+		return "", err
+	}
+	code, err := multicodecOf(keyType, private)
+	if err != nil {
+		return "", err
+	}
+	data := append(varint.ToUvarint(uint64(code)), key...)
+	return multibase.Encode(multibase.Base58BTC, data)
+}
+
+// DecodeMultikey decodes a Multikey and reports whether it is private.
+func DecodeMultikey(multikey string) (string, []byte, bool, error) {
+	_, data, err := multibase.Decode(multikey)
+	if err != nil {
+		return "", nil, false, xerrors.Errorf("invalid multikey: %v", err)
+	}
+	code, n, err := varint.FromUvarint(data)
+	if err != nil {
+		return "", nil, false, xerrors.Errorf("invalid multikey: %v", err)
+	}
+	key := data[n:]
+	var keyType string
+	private := false
+	switch codec.Code(code) {
+	case codec.Secp256k1Pub:
+		keyType = KeyTypeSecp256k1
+	case codec.Ed25519Pub:
+		keyType = KeyTypeEd25519
+	case codec.Secp256k1Priv:
+		keyType, private = KeyTypeSecp256k1, true
+	case codec.Ed25519Priv:
+		keyType, private = KeyTypeEd25519, true
+	default:
+		return "", nil, false, xerrors.Errorf("unsupported multikey codec %x", code)
+	}
+	if private {
+		err = validatePrivateKey(keyType, key)
+	} else {
+		err = validatePublicKey(keyType, key)
+	}
+	if err != nil {
+		return "", nil, false, err
+	}
+	return keyType, key, private, nil
+}
+
+func multicodecOf(keyType string, private bool) (codec.Code, error) {
+	switch {
+	case keyType == KeyTypeSecp256k1 && private:
+		return codec.Secp256k1Priv, nil
+	case keyType == KeyTypeSecp256k1:
+		return codec.Secp256k1Pub, nil
+	case keyType == KeyTypeEd25519 && private:
+		return codec.Ed25519Priv, nil
+	case keyType == KeyTypeEd25519:
+		return codec.Ed25519Pub, nil
+	default:
+		return 0, unsupportedKeyType(keyType)
+	}
+}
+
+func validatePrivateKey(keyType string, privateKey []byte) error {
+	switch keyType {
+	case KeyTypeSecp256k1:
+		if len(privateKey) != 32 {
+			return xerrors.Errorf("invalid secp256k1 private key length %d", len(privateKey))
+		}
+		d := new(big.Int).SetBytes(privateKey)
+		if d.Sign() == 0 || d.Cmp(btcec.S256().N) >= 0 {
+			return xerrors.New("invalid secp256k1 private key: out of range")
+		}
+	case KeyTypeEd25519:
+		if len(privateKey) != ed25519.SeedSize {
+			return xerrors.Errorf("invalid ed25519 private key length %d", len(privateKey))
+		}
+	default:
+		return unsupportedKeyType(keyType)
+	}
+	return nil
+}
+
+func validatePublicKey(keyType string, publicKey []byte) error {
+	switch keyType {
+	case KeyTypeSecp256k1:
+		if len(publicKey) != btcec.PubKeyBytesLenCompressed {
+			return xerrors.Errorf("invalid secp256k1 public key length %d", len(publicKey))
+		}
+		if _, err := btcec.ParsePubKey(publicKey, btcec.S256()); err != nil {
+			return xerrors.Errorf("invalid secp256k1 public key: %v", err)
+		}
+	case KeyTypeEd25519:
+		if len(publicKey) != ed25519.PublicKeySize {
+			return xerrors.Errorf("invalid ed25519 public key length %d", len(publicKey))
+		}
+	default:
+		return unsupportedKeyType(keyType)
+	}
+	return nil
+}
+
+func unsupportedKeyType(keyType string) error {
+	return xerrors.Errorf("unsupported key type %s", keyType)
+}
+
+// padKey left pads a big-endian scalar to 32 bytes.
+func padKey(b []byte) []byte {
+	key := make([]byte, 32)
+	copy(key[32-len(b):], b)
+	return key
+}
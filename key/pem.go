@@ -0,0 +1,175 @@
+package key
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+
+	"github.com/btcsuite/btcd/btcec"
+	"golang.org/x/xerrors"
+)
+
+var (
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSecp256k1      = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// the crypto/x509 structures, secp256k1 is not a curve x509 knows
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+type ecPrivateKey struct {
+	Version    int
+	PrivateKey []byte
+	PublicKey  asn1.BitString `asn1:"optional,explicit,tag:1"`
+}
+
+type spki struct {
+	Algo      pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// EncodePrivatePEM encodes a private key of keyType as a PKCS#8 "PRIVATE
+// KEY" PEM block.
+func EncodePrivatePEM(keyType string, privateKey []byte) ([]byte, error) {
+	if err := validatePrivateKey(keyType, privateKey); err != nil {
+		return nil, err
+	}
+	var der []byte
+	var err error
+	if keyType == KeyTypeEd25519 {
+		der, err = x509.MarshalPKCS8PrivateKey(ed25519.NewKeyFromSeed(privateKey))
+	} else {
+		_, pubKey := btcec.PrivKeyFromBytes(btcec.S256(), privateKey)
+		var inner []byte
+		inner, err = asn1.Marshal(ecPrivateKey{
+			Version:    1,
+			PrivateKey: privateKey,
+			PublicKey:  asn1.BitString{Bytes: pubKey.SerializeUncompressed(), BitLength: 65 * 8},
+		})
+		if err != nil {
+			return nil, err
+		}
+		der, err = asn1.Marshal(pkcs8{Algo: secp256k1Algorithm(), PrivateKey: inner})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// EncodePublicPEM encodes a public key of keyType as an SPKI "PUBLIC KEY"
+// PEM block.
+func EncodePublicPEM(keyType string, publicKey []byte) ([]byte, error) {
+	if err := validatePublicKey(keyType, publicKey); err != nil {
+		return nil, err
+	}
+	var der []byte
+	var err error
+	if keyType == KeyTypeEd25519 {
+		der, err = x509.MarshalPKIXPublicKey(ed25519.PublicKey(publicKey))
+	} else {
+		pubKey, _ := btcec.ParsePubKey(publicKey, btcec.S256())
+		der, err = asn1.Marshal(spki{
+			Algo:      secp256k1Algorithm(),
+			PublicKey: asn1.BitString{Bytes: pubKey.SerializeUncompressed(), BitLength: 65 * 8},
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// DecodePEM decodes a PKCS#8 private key or SPKI public key PEM block and
+// reports whether it is private.
+func DecodePEM(data []byte) (string, []byte, bool, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return "", nil, false, xerrors.New("invalid pem: no pem block")
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		keyType, privateKey, err := decodePKCS8(block.Bytes)
+		return keyType, privateKey, true, err
+	case "PUBLIC KEY":
+		keyType, publicKey, err := decodeSPKI(block.Bytes)
+		return keyType, publicKey, false, err
+	default:
+		return "", nil, false, xerrors.Errorf("unsupported pem block %s", block.Type)
+	}
+}
+
+func decodePKCS8(der []byte) (string, []byte, error) {
+	var key pkcs8
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return "", nil, xerrors.Errorf("invalid pkcs8: %v", err)
+	}
+	if !isSecp256k1(key.Algo) {
+		parsed, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return "", nil, xerrors.Errorf("invalid pkcs8: %v", err)
+		}
+		edKey, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return "", nil, xerrors.New("unsupported pkcs8 key type")
+		}
+		return KeyTypeEd25519, edKey.Seed(), nil
+	}
+	var inner ecPrivateKey
+	if _, err := asn1.Unmarshal(key.PrivateKey, &inner); err != nil {
+		return "", nil, xerrors.Errorf("invalid ec private key: %v", err)
+	}
+	if len(inner.PrivateKey) > 32 {
+		return "", nil, xerrors.New("invalid ec private key length")
+	}
+	privateKey := padKey(inner.PrivateKey)
+	if err := validatePrivateKey(KeyTypeSecp256k1, privateKey); err != nil {
+		return "", nil, err
+	}
+	return KeyTypeSecp256k1, privateKey, nil
+}
+
+func decodeSPKI(der []byte) (string, []byte, error) {
+	var key spki
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return "", nil, xerrors.Errorf("invalid spki: %v", err)
+	}
+	if !isSecp256k1(key.Algo) {
+		parsed, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return "", nil, xerrors.Errorf("invalid spki: %v", err)
+		}
+		edKey, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return "", nil, xerrors.New("unsupported spki key type")
+		}
+		return KeyTypeEd25519, edKey, nil
+	}
+	pubKey, err := btcec.ParsePubKey(key.PublicKey.Bytes, btcec.S256())
+	if err != nil {
+		return "", nil, xerrors.Errorf("invalid secp256k1 public key: %v", err)
+	}
+	return KeyTypeSecp256k1, pubKey.SerializeCompressed(), nil
+}
+
+func secp256k1Algorithm() pkix.AlgorithmIdentifier {
+	curve, _ := asn1.Marshal(oidSecp256k1)
+	return pkix.AlgorithmIdentifier{Algorithm: oidPublicKeyECDSA, Parameters: asn1.RawValue{FullBytes: curve}}
+}
+
+func isSecp256k1(algo pkix.AlgorithmIdentifier) bool {
+	if !algo.Algorithm.Equal(oidPublicKeyECDSA) {
+		return false
+	}
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(algo.Parameters.FullBytes, &curve); err != nil {
+		return false
+	}
+	return curve.Equal(oidSecp256k1)
+}
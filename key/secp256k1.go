@@ -30,5 +30,8 @@ func (s Secp256k1KeyResolver) ResolveKey(pubKeyBytes []byte, fingerprint string)
 		Authentication: []any{
 			keyId,
 		},
+		AssertionMethod: []any{
+			keyId,
+		},
 	}, nil
 }
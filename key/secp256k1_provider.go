@@ -2,88 +2,173 @@ package key
 
 import (
 	"encoding/json"
-	"fmt"
 	"strings"
-	"time"
 
 	saodid "github.com/SaoNetwork/sao-did/types"
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	"github.com/dvsekhvalnov/jose2go/base64url"
-	"github.com/multiformats/go-multibase"
+	"github.com/thanhpk/randstr"
+	"golang.org/x/xerrors"
 )
 
 type Secp256k1Provider struct {
-	did       string
-	secretKey []byte
+	did    string
+	signer saodid.Signer
+	clock  saodid.Clock
 }
 
+// NewSecp256k1Provider derives the private key from secretKey with
+// secp256k1.GenPrivKeyFromSecret.
 func NewSecp256k1Provider(secretKey []byte) (*Secp256k1Provider, error) {
-	privKey := secp256k1.GenPrivKeyFromSecret(secretKey)
-	pubKey := privKey.PubKey()
+	return NewSecp256k1ProviderFromKey(secp256k1.GenPrivKeyFromSecret(secretKey).Bytes())
+}
 
-	did, err := encodeDid(pubKey.Bytes())
+// NewSecp256k1ProviderFromKey uses privateKey, a raw 32 byte secp256k1
+// private key, as is.
+func NewSecp256k1ProviderFromKey(privateKey []byte) (*Secp256k1Provider, error) {
+	signer, err := NewSecp256k1Signer(privateKey)
 	if err != nil {
 		return nil, err
 	}
-	return &Secp256k1Provider{did, secretKey}, nil
+	return NewSecp256k1ProviderFromSigner(signer)
 }
 
-func encodeDid(pubKey []byte) (string, error) {
-	bytes := append([]byte{0xe7, 0x01}, pubKey...)
-	encoded, err := multibase.Encode(multibase.Base58BTC, bytes)
+// NewSecp256k1ProviderFromSigner delegates signing to an ES256K signer.
+func NewSecp256k1ProviderFromSigner(signer saodid.Signer) (*Secp256k1Provider, error) {
+	if signer == nil {
+		return nil, xerrors.New("signer is missing.")
+	}
+	if signer.Algorithm() != saodid.AlgES256K {
+		return nil, xerrors.Errorf("signer algorithm %s is not %s", signer.Algorithm(), saodid.AlgES256K)
+	}
+	pubKey := signer.PublicKey()
+	if len(pubKey) != secp256k1.PubKeySize {
+		return nil, xerrors.Errorf("invalid secp256k1 public key length %d", len(pubKey))
+	}
+	did, err := EncodeDidKey(KeyTypeSecp256k1, pubKey)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return fmt.Sprintf("did:key:" + encoded), nil
+	return &Secp256k1Provider{did: did, signer: signer}, nil
+}
+
+func (s *Secp256k1Provider) Did() string {
+	return s.did
+}
+
+// SetClock sets the clock the expiry of authentication responses is based
+// on, the system clock by default.
+func (s *Secp256k1Provider) SetClock(clock saodid.Clock) {
+	s.clock = clock
 }
 
 func (s *Secp256k1Provider) Authenticate(params saodid.AuthParams) (saodid.GeneralJWS, error) {
-	payload := saodid.Payload{
-		Did:   s.did,
-		Aud:   params.Aud,
-		Nonce: params.Nonce,
-		Paths: params.Paths,
-		Exp:   time.Now().Unix() + 600,
-	}
-	payloadBytes, err := json.Marshal(payload)
+	payload, err := authPayload(s.did, s.clock, params)
 	if err != nil {
 		return saodid.GeneralJWS{}, err
 	}
-	return s.CreateJWS(payloadBytes)
+	return s.CreateJWS(payload)
+}
+
+// authPayload builds the authentication response payload of did.
+func authPayload(did string, clock saodid.Clock, params saodid.AuthParams) ([]byte, error) {
+	if clock == nil {
+		clock = saodid.SystemClock{}
+	}
+	lifetime := params.Lifetime
+	if lifetime == 0 {
+		lifetime = saodid.DefaultAuthLifetime
+	}
+	return json.Marshal(saodid.Payload{
+		Did:   did,
+		Aud:   params.Aud,
+		Nonce: params.Nonce,
+		Paths: params.Paths,
+		Exp:   clock.Now().Add(lifetime).Unix(),
+		Jti:   randstr.String(16),
+	})
 }
 
 func (s *Secp256k1Provider) CreateJWS(
 	payload []byte,
+	options ...saodid.CreateJWSOptions,
 ) (saodid.GeneralJWS, error) {
 	splits := strings.Split(s.did, ":")
 	kid := s.did + "#" + splits[2]
-	signer := secp256k1.GenPrivKeyFromSecret(s.secretKey)
-	return createJWS(payload, signer, saodid.JWTHeader{Kid: kid, Alg: "ES256K"})
+	return createJWS(payload, s.signer, saodid.JWTHeader{Kid: kid, Alg: saodid.AlgES256K}, options...)
+}
+
+// Sign signs arbitrary data with the provider key, the message is hashed
+// with SHA-256 before signing as for ES256K.
+func (s *Secp256k1Provider) Sign(data []byte) ([]byte, error) {
+	return s.signer.Sign(data)
 }
 
 func createJWS(
 	payload []byte,
-	signer *secp256k1.PrivKey,
+	signer saodid.Signer,
 	header saodid.JWTHeader,
+	options ...saodid.CreateJWSOptions,
 ) (saodid.GeneralJWS, error) {
-	headerBytes, err := json.Marshal(header)
+	var opts saodid.CreateJWSOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if len(payload) == 0 {
+		return saodid.GeneralJWS{}, xerrors.New("payload is missing.")
+	}
+	headerBytes, err := marshalHeader(header, opts)
 	if err != nil {
 		return saodid.GeneralJWS{}, err
 	}
 	encodedPayload := encodeSection(payload)
+	if opts.Unencoded {
+		encodedPayload = string(payload)
+	}
 	protectedHeader := encodeSection(headerBytes)
 	input := protectedHeader + "." + encodedPayload
 	sig, err := signer.Sign([]byte(input))
 	if err != nil {
 		return saodid.GeneralJWS{}, err
 	}
-	return saodid.GeneralJWS{
+	jws := saodid.GeneralJWS{
 		Payload: encodedPayload,
 		Signatures: []saodid.JwsSignature{{
 			Protected: protectedHeader,
 			Signature: encodeSection(sig),
 		}},
-	}, nil
+	}
+	if opts.Detached {
+		jws = jws.Detach()
+	}
+	return jws, nil
+}
+
+// marshalHeader merges the extra protected header parameters of the
+// options with kid and alg.
+func marshalHeader(header saodid.JWTHeader, options saodid.CreateJWSOptions) ([]byte, error) {
+	if len(options.ProtectedHeader) == 0 && !options.Unencoded {
+		return json.Marshal(header)
+	}
+	fields := make(map[string]any, len(options.ProtectedHeader)+4)
+	for k, v := range options.ProtectedHeader {
+		fields[k] = v
+	}
+	fields["kid"] = header.Kid
+	fields["alg"] = header.Alg
+	if options.Unencoded {
+		fields["b64"] = false
+		crit := []string{"b64"}
+		if existing, ok := fields["crit"].([]string); ok {
+			for _, c := range existing {
+				if c != "b64" {
+					crit = append(crit, c)
+				}
+			}
+		}
+		fields["crit"] = crit
+	}
+	return json.Marshal(fields)
 }
 
 func encodeSection(data []byte) string {
@@ -0,0 +1,76 @@
+package key
+
+import (
+	"crypto/ed25519"
+
+	saodid "github.com/SaoNetwork/sao-did/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"golang.org/x/xerrors"
+)
+
+// Secp256k1Signer is an in-memory ES256K signer.
+type Secp256k1Signer struct {
+	privKey *secp256k1.PrivKey
+}
+
+// NewSecp256k1Signer uses privateKey, a raw 32 byte secp256k1 private key.
+func NewSecp256k1Signer(privateKey []byte) (*Secp256k1Signer, error) {
+	if len(privateKey) != secp256k1.PrivKeySize {
+		return nil, xerrors.Errorf("invalid secp256k1 private key length %d", len(privateKey))
+	}
+	key := make([]byte, len(privateKey))
+	copy(key, privateKey)
+	return &Secp256k1Signer{privKey: &secp256k1.PrivKey{Key: key}}, nil
+}
+
+func (s *Secp256k1Signer) PublicKey() []byte {
+	return s.privKey.PubKey().Bytes()
+}
+
+func (s *Secp256k1Signer) Algorithm() string {
+	return saodid.AlgES256K
+}
+
+func (s *Secp256k1Signer) Sign(data []byte) ([]byte, error) {
+	return s.privKey.Sign(data)
+}
+
+// Ed25519Signer is an in-memory EdDSA signer.
+type Ed25519Signer struct {
+	privKey ed25519.PrivateKey
+}
+
+// NewEd25519Signer uses a 32 byte Ed25519 seed.
+func NewEd25519Signer(seed []byte) (*Ed25519Signer, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, xerrors.Errorf("invalid ed25519 seed length %d", len(seed))
+	}
+	return &Ed25519Signer{privKey: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+func (e *Ed25519Signer) PublicKey() []byte {
+	return e.privKey.Public().(ed25519.PublicKey)
+}
+
+func (e *Ed25519Signer) Algorithm() string {
+	return saodid.AlgEdDSA
+}
+
+func (e *Ed25519Signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(e.privKey, data), nil
+}
+
+// NewProvider returns the did:key provider of the signer's key type.
+func NewProvider(signer saodid.Signer) (saodid.DidProvider, error) {
+	if signer == nil {
+		return nil, xerrors.New("signer is missing.")
+	}
+	switch signer.Algorithm() {
+	case saodid.AlgES256K:
+		return NewSecp256k1ProviderFromSigner(signer)
+	case saodid.AlgEdDSA:
+		return NewEd25519ProviderFromSigner(signer)
+	default:
+		return nil, xerrors.Errorf("unsupported signer algorithm %s", signer.Algorithm())
+	}
+}
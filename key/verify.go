@@ -0,0 +1,57 @@
+package key
+
+import (
+	"crypto/ed25519"
+
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"github.com/mr-tron/base58"
+	"github.com/multiformats/go-multibase"
+	"golang.org/x/xerrors"
+)
+
+// JWSAlgorithm returns the JWS alg of a verification method type, empty
+// for types that do not sign.
+func JWSAlgorithm(vmType string) string {
+	switch vmType {
+	case Ed25519VerificationKey2018, "Ed25519VerificationKey2020":
+		return saotypes.AlgEdDSA
+	case "Secp256k1VerificationKey2018", "EcdsaSecp256k1VerificationKey2019", "EcdsaSecp256k1Signature2019":
+		return saotypes.AlgES256K
+	default:
+		return ""
+	}
+}
+
+// VerifySignature checks that sig is a valid signature of data made by one
+// of the verification methods.
+func VerifySignature(data []byte, sig []byte, vms []saotypes.VerificationMethod) error {
+	for _, vm := range vms {
+		var rawPk []byte
+		var err error
+		if vm.PublicKeyBase58 != "" {
+			rawPk, err = base58.Decode(vm.PublicKeyBase58)
+		} else if vm.PublicKeyMultibase != "" {
+			_, rawPk, err = multibase.Decode(vm.PublicKeyMultibase)
+		}
+		if err != nil {
+			return xerrors.Errorf("decode pubKey failed: %v", err)
+		}
+
+		if rawPk == nil {
+			continue
+		}
+		switch vm.Type {
+		case Ed25519VerificationKey2018, "Ed25519VerificationKey2020":
+			if len(rawPk) == ed25519.PublicKeySize && ed25519.Verify(rawPk, data, sig) {
+				return nil
+			}
+		default:
+			pubkey := secp256k1.PubKey{Key: rawPk}
+			if pubkey.VerifySignature(data, sig) {
+				return nil
+			}
+		}
+	}
+	return xerrors.New("invalid_signature: Signature invalid for JWT")
+}
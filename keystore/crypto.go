@@ -0,0 +1,222 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+	"golang.org/x/xerrors"
+)
+
+const (
+	KdfScrypt   = "scrypt"
+	KdfArgon2id = "argon2id"
+
+	// CipherAes128Ctr with a keccak-256 MAC is the Web3 Secret Storage
+	// cipher, CipherAes256Gcm authenticates the key itself.
+	CipherAes128Ctr = "aes-128-ctr"
+	CipherAes256Gcm = "aes-256-gcm"
+)
+
+// Scrypt parameters, StandardScrypt as in Web3 Secret Storage and
+// LightScrypt for constrained devices and tests.
+var (
+	StandardScrypt = KdfParams{N: 1 << 18, R: 8, P: 1}
+	LightScrypt    = KdfParams{N: 1 << 12, R: 8, P: 6}
+	// DefaultArgon2id follows the RFC 9106 second recommendation.
+	DefaultArgon2id = KdfParams{Time: 3, Memory: 64 * 1024, Threads: 4}
+)
+
+// Limits of the KDF parameters read from key files, a crafted file must not
+// exhaust memory or CPU. They are well above the parameters used here.
+const (
+	maxScryptMemory  = 1 << 30 // bytes, 128 * N * r
+	maxScryptP       = 16
+	maxArgon2Memory  = 1 << 20 // KiB
+	maxArgon2Time    = 16
+	maxArgon2Threads = 16
+	maxDkLen         = 64
+)
+
+// CryptoJSON is the crypto section of a Web3 Secret Storage v3 key file.
+type CryptoJSON struct {
+	Cipher       string       `json:"cipher"`
+	CipherText   string       `json:"ciphertext"`
+	CipherParams CipherParams `json:"cipherparams"`
+	Kdf          string       `json:"kdf"`
+	KdfParams    KdfParams    `json:"kdfparams"`
+	Mac          string       `json:"mac,omitempty"`
+}
+
+type CipherParams struct {
+	Iv    string `json:"iv,omitempty"`
+	Nonce string `json:"nonce,omitempty"`
+}
+
+// KdfParams holds the parameters of either KDF, the unused ones are empty.
+type KdfParams struct {
+	DkLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+	// scrypt
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+	// argon2id, Memory in KiB
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+}
+
+func encryptKey(key []byte, passphrase string, kdf string, params KdfParams, cipherName string) (CryptoJSON, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return CryptoJSON{}, err
+	}
+	params.Salt = hex.EncodeToString(salt)
+	params.DkLen = 32
+	derived, err := deriveKey(passphrase, kdf, params)
+	if err != nil {
+		return CryptoJSON{}, err
+	}
+	result := CryptoJSON{Cipher: cipherName, Kdf: kdf, KdfParams: params}
+
+	switch cipherName {
+	case CipherAes128Ctr:
+		iv := make([]byte, aes.BlockSize)
+		if _, err = rand.Read(iv); err != nil {
+			return CryptoJSON{}, err
+		}
+		cipherText, err := aesCtr(derived[:16], iv, key)
+		if err != nil {
+			return CryptoJSON{}, err
+		}
+		result.CipherText = hex.EncodeToString(cipherText)
+		result.CipherParams.Iv = hex.EncodeToString(iv)
+		result.Mac = hex.EncodeToString(keccak256(derived[16:32], cipherText))
+	case CipherAes256Gcm:
+		aead, err := newGcm(derived)
+		if err != nil {
+			return CryptoJSON{}, err
+		}
+		nonce := make([]byte, aead.NonceSize())
+		if _, err = rand.Read(nonce); err != nil {
+			return CryptoJSON{}, err
+		}
+		result.CipherText = hex.EncodeToString(aead.Seal(nil, nonce, key, nil))
+		result.CipherParams.Nonce = hex.EncodeToString(nonce)
+	default:
+		return CryptoJSON{}, xerrors.Errorf("unsupported cipher %s", cipherName)
+	}
+	return result, nil
+}
+
+func decryptKey(c CryptoJSON, passphrase string) ([]byte, error) {
+	derived, err := deriveKey(passphrase, c.Kdf, c.KdfParams)
+	if err != nil {
+		return nil, err
+	}
+	if len(derived) < 32 {
+		return nil, xerrors.New("derived key is too short")
+	}
+	cipherText, err := hex.DecodeString(c.CipherText)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid ciphertext: %v", err)
+	}
+
+	switch c.Cipher {
+	case CipherAes128Ctr:
+		mac, err := hex.DecodeString(c.Mac)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid mac: %v", err)
+		}
+		if !bytes.Equal(keccak256(derived[16:32], cipherText), mac) {
+			return nil, ErrDecrypt
+		}
+		iv, err := hex.DecodeString(c.CipherParams.Iv)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid iv: %v", err)
+		}
+		return aesCtr(derived[:16], iv, cipherText)
+	case CipherAes256Gcm:
+		aead, err := newGcm(derived[:32])
+		if err != nil {
+			return nil, err
+		}
+		nonce, err := hex.DecodeString(c.CipherParams.Nonce)
+		if err != nil || len(nonce) != aead.NonceSize() {
+			return nil, xerrors.New("invalid nonce")
+		}
+		key, err := aead.Open(nil, nonce, cipherText, nil)
+		if err != nil {
+			return nil, ErrDecrypt
+		}
+		return key, nil
+	default:
+		return nil, xerrors.Errorf("unsupported cipher %s", c.Cipher)
+	}
+}
+
+func deriveKey(passphrase string, kdf string, params KdfParams) ([]byte, error) {
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid salt: %v", err)
+	}
+	if params.DkLen <= 0 || params.DkLen > maxDkLen {
+		return nil, xerrors.Errorf("invalid dklen %d", params.DkLen)
+	}
+	switch kdf {
+	case KdfScrypt:
+		n, r, p := params.N, params.R, params.P
+		if n <= 1 || n&(n-1) != 0 {
+			return nil, xerrors.New("invalid scrypt parameters: n should be a power of two")
+		}
+		if r <= 0 || p <= 0 || p > maxScryptP || n > maxScryptMemory/128/r {
+			return nil, xerrors.New("invalid scrypt parameters: too expensive")
+		}
+		return scrypt.Key([]byte(passphrase), salt, n, r, p, params.DkLen)
+	case KdfArgon2id:
+		if params.Time == 0 || params.Memory == 0 || params.Threads == 0 {
+			return nil, xerrors.New("invalid argon2id parameters")
+		}
+		if params.Time > maxArgon2Time || params.Memory > maxArgon2Memory || params.Threads > maxArgon2Threads {
+			return nil, xerrors.New("invalid argon2id parameters: too expensive")
+		}
+		return argon2.IDKey([]byte(passphrase), salt, params.Time, params.Memory, params.Threads, uint32(params.DkLen)), nil
+	default:
+		return nil, xerrors.Errorf("unsupported kdf %s", kdf)
+	}
+}
+
+func aesCtr(key, iv, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, xerrors.New("invalid iv")
+	}
+	out := make([]byte, len(data))
+	cipher.NewCTR(block, iv).XORKeyStream(out, data)
+	return out, nil
+}
+
+func newGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
@@ -0,0 +1,56 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// scrypt test vector of the Web3 Secret Storage definition
+func TestDecryptWeb3Vector(t *testing.T) {
+	c := CryptoJSON{
+		Cipher:       CipherAes128Ctr,
+		CipherText:   "d172bf743a674da9cdad04534d56926ef8358534d458fffccd4e6ad2fbde479c",
+		CipherParams: CipherParams{Iv: "83dbcc02d8ccb40e466191a123791e0e"},
+		Kdf:          KdfScrypt,
+		KdfParams: KdfParams{
+			DkLen: 32,
+			N:     262144,
+			P:     8,
+			R:     1,
+			Salt:  "ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19",
+		},
+		Mac: "2103ac29920d71da29f15d75b4a16dbe95cfd7ff8faea1056c33131d846e3097",
+	}
+	key, err := decryptKey(c, "testpassword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(key) != "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d" {
+		t.Fatalf("unexpected key %x", key)
+	}
+	if _, err = decryptKey(c, "wrong"); err != ErrDecrypt {
+		t.Fatalf("expected ErrDecrypt, got %v", err)
+	}
+}
+
+func TestDeriveKeyLimits(t *testing.T) {
+	salt := "ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19"
+	cases := []struct {
+		kdf    string
+		params KdfParams
+	}{
+		{KdfScrypt, KdfParams{DkLen: 32, N: 1000, R: 8, P: 1}},
+		{KdfScrypt, KdfParams{DkLen: 32, N: 1 << 30, R: 8, P: 1}},
+		{KdfScrypt, KdfParams{DkLen: 32, N: 1 << 12, R: 1 << 20, P: 1}},
+		{KdfScrypt, KdfParams{DkLen: 32, N: 1 << 12, R: 8, P: 1 << 20}},
+		{KdfScrypt, KdfParams{DkLen: 1 << 30, N: 1 << 12, R: 8, P: 1}},
+		{KdfArgon2id, KdfParams{DkLen: 32, Time: 1, Memory: 1 << 30, Threads: 1}},
+		{KdfArgon2id, KdfParams{DkLen: 32, Time: 1 << 30, Memory: 1024, Threads: 1}},
+	}
+	for _, c := range cases {
+		c.params.Salt = salt
+		if _, err := deriveKey("passphrase", c.kdf, c.params); err == nil {
+			t.Errorf("%s %+v accepted", c.kdf, c.params)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package keystore keeps provider keys in a directory of encrypted key
+// files, one per identity. The files use the Web3 Secret Storage v3
+// format, with a did and a key type in place of the address.
+package keystore
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/SaoNetwork/sao-did/key"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"golang.org/x/xerrors"
+)
+
+// Key types of the stored keys.
+const (
+	KeyTypeSecp256k1 = key.KeyTypeSecp256k1
+	KeyTypeEd25519   = key.KeyTypeEd25519
+)
+
+var (
+	ErrDecrypt  = xerrors.New("could not decrypt key with given passphrase")
+	ErrNotFound = xerrors.New("no key for given did")
+)
+
+// KeyFile is the content of a key file.
+type KeyFile struct {
+	Version int        `json:"version"`
+	Id      string     `json:"id"`
+	Did     string     `json:"did"`
+	KeyType string     `json:"keyType"`
+	Crypto  CryptoJSON `json:"crypto"`
+}
+
+// Options select how new keys are encrypted.
+type Options struct {
+	// Kdf is KdfScrypt, the default, or KdfArgon2id.
+	Kdf string
+	// KdfParams default to StandardScrypt or DefaultArgon2id.
+	KdfParams KdfParams
+	// Cipher is CipherAes256Gcm, the default, or CipherAes128Ctr for files
+	// readable by Web3 Secret Storage tools.
+	Cipher string
+}
+
+type Store struct {
+	dir     string
+	options Options
+}
+
+// NewStore opens the key directory dir, it is created when missing.
+func NewStore(dir string, options Options) (*Store, error) {
+	if dir == "" {
+		return nil, xerrors.New("keystore directory is missing.")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if options.Kdf == "" {
+		options.Kdf = KdfScrypt
+	}
+	if options.KdfParams == (KdfParams{}) {
+		if options.Kdf == KdfArgon2id {
+			options.KdfParams = DefaultArgon2id
+		} else {
+			options.KdfParams = StandardScrypt
+		}
+	}
+	if options.Cipher == "" {
+		options.Cipher = CipherAes256Gcm
+	}
+	return &Store{dir: dir, options: options}, nil
+}
+
+// Generate creates and stores a new secp256k1 identity and returns its DID.
+func (s *Store) Generate(passphrase string) (string, error) {
+	privateKey, err := key.GenerateKey(KeyTypeSecp256k1)
+	if err != nil {
+		return "", err
+	}
+	return s.Import(KeyTypeSecp256k1, privateKey, passphrase)
+}
+
+// Import stores a raw private key of keyType and returns its DID.
+func (s *Store) Import(keyType string, privateKey []byte, passphrase string) (string, error) {
+	provider, err := key.NewProviderFromKey(keyType, privateKey)
+	if err != nil {
+		return "", err
+	}
+	did := provider.Did()
+	path, err := s.path(did)
+	if err != nil {
+		return "", err
+	}
+
+	crypto, err := encryptKey(privateKey, passphrase, s.options.Kdf, s.options.KdfParams, s.options.Cipher)
+	if err != nil {
+		return "", err
+	}
+	id, err := newUUID()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(KeyFile{Version: 3, Id: id, Did: did, KeyType: keyType, Crypto: crypto}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		return "", xerrors.Errorf("key for %s already exists", did)
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		os.Remove(path)
+		return "", err
+	}
+	if err = file.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return did, nil
+}
+
+// List returns the DIDs in the store.
+func (s *Store) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var dids []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		keyFile, err := readKeyFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		dids = append(dids, keyFile.Did)
+	}
+	sort.Strings(dids)
+	return dids, nil
+}
+
+// Unlock decrypts the key of did and returns its provider.
+func (s *Store) Unlock(did string, passphrase string) (saotypes.DidProvider, error) {
+	path, err := s.path(did)
+	if err != nil {
+		return nil, err
+	}
+	keyFile, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if keyFile.Did != did {
+		return nil, xerrors.Errorf("key file of %s holds the key of %s", did, keyFile.Did)
+	}
+	privateKey, err := decryptKey(keyFile.Crypto, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := key.NewProviderFromKey(keyFile.KeyType, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if provider.Did() != keyFile.Did {
+		return nil, xerrors.Errorf("key file of %s holds the key of %s", keyFile.Did, provider.Did())
+	}
+	return provider, nil
+}
+
+// Delete removes the key of did after checking the passphrase.
+func (s *Store) Delete(did string, passphrase string) error {
+	if _, err := s.Unlock(did, passphrase); err != nil {
+		return err
+	}
+	path, err := s.path(did)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// path returns the key file of did, which must not leave the store
+// directory.
+func (s *Store) path(did string) (string, error) {
+	name := strings.ReplaceAll(did, ":", "_") + ".json"
+	if did == "" || strings.Contains(did, "..") || strings.ContainsAny(did, `/\`) || filepath.Base(name) != name {
+		return "", xerrors.Errorf("invalid did %q", did)
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+func readKeyFile(path string) (KeyFile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return KeyFile{}, ErrNotFound
+	}
+	if err != nil {
+		return KeyFile{}, err
+	}
+	var keyFile KeyFile
+	if err = json.Unmarshal(data, &keyFile); err != nil {
+		return KeyFile{}, xerrors.Errorf("parse key file failed: %v", err)
+	}
+	if keyFile.Version != 3 {
+		return KeyFile{}, xerrors.Errorf("unsupported key file version %d", keyFile.Version)
+	}
+	return keyFile, nil
+}
+
+func newUUID() (string, error) {
+	u := make([]byte, 16)
+	if _, err := rand.Read(u); err != nil {
+		return "", err
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:]), nil
+}
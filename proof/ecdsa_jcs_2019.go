@@ -0,0 +1,83 @@
+package proof
+
+import (
+	"crypto/sha256"
+
+	"github.com/SaoNetwork/sao-did/key"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"github.com/multiformats/go-multibase"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DataIntegrityProofType = "DataIntegrityProof"
+	EcdsaJcs2019           = "ecdsa-jcs-2019"
+)
+
+// EcdsaJcs2019Suite is the ecdsa-jcs-2019 cryptosuite,
+// https://www.w3.org/TR/vc-di-ecdsa/#ecdsa-jcs-2019, applied to the
+// secp256k1 keys of SAO identities.
+type EcdsaJcs2019Suite struct{}
+
+func NewEcdsaJcs2019() EcdsaJcs2019Suite {
+	return EcdsaJcs2019Suite{}
+}
+
+func (s EcdsaJcs2019Suite) Matches(proof map[string]any) bool {
+	return proof["type"] == DataIntegrityProofType && proof["cryptosuite"] == EcdsaJcs2019
+}
+
+func (s EcdsaJcs2019Suite) Prepare(document map[string]any, proof map[string]any) {
+	proof["type"] = DataIntegrityProofType
+	proof["cryptosuite"] = EcdsaJcs2019
+}
+
+func (s EcdsaJcs2019Suite) CreateProof(document map[string]any, proof map[string]any, signer Signer) error {
+	hashData, err := s.hashData(document, proof)
+	if err != nil {
+		return err
+	}
+	sig, err := signer.Sign(hashData)
+	if err != nil {
+		return err
+	}
+	proof["proofValue"], err = multibase.Encode(multibase.Base58BTC, sig)
+	return err
+}
+
+func (s EcdsaJcs2019Suite) VerifyProof(document map[string]any, proof map[string]any, vm saotypes.VerificationMethod) error {
+	proofValue, _ := proof["proofValue"].(string)
+	if proofValue == "" {
+		return xerrors.New("proof has no proofValue")
+	}
+	_, sig, err := multibase.Decode(proofValue)
+	if err != nil {
+		return xerrors.Errorf("decode proofValue failed: %v", err)
+	}
+	delete(proof, "proofValue")
+	hashData, err := s.hashData(document, proof)
+	if err != nil {
+		return err
+	}
+	return key.VerifySignature(hashData, sig, []saotypes.VerificationMethod{vm})
+}
+
+// hashData is the SHA-256 hash of the canonical proof configuration
+// followed by the SHA-256 hash of the canonical document.
+func (s EcdsaJcs2019Suite) hashData(document map[string]any, proof map[string]any) ([]byte, error) {
+	proofConfig := copyDocument(proof)
+	if ctx, ok := document["@context"]; ok {
+		proofConfig["@context"] = ctx
+	}
+	canonicalProofConfig, err := CanonicalizeValue(proofConfig)
+	if err != nil {
+		return nil, err
+	}
+	canonicalDocument, err := CanonicalizeValue(document)
+	if err != nil {
+		return nil, err
+	}
+	proofHash := sha256.Sum256(canonicalProofConfig)
+	documentHash := sha256.Sum256(canonicalDocument)
+	return append(proofHash[:], documentHash[:]...), nil
+}
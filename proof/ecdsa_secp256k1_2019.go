@@ -0,0 +1,150 @@
+package proof
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"strings"
+
+	"github.com/SaoNetwork/sao-did/key"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"github.com/dvsekhvalnov/jose2go/base64url"
+	"github.com/piprate/json-gold/ld"
+	"golang.org/x/xerrors"
+)
+
+const EcdsaSecp256k1Signature2019Type = "EcdsaSecp256k1Signature2019"
+
+// detachedJWSHeader is the protected header of the unencoded, detached
+// JWS (RFC 7797) the suite stores in the jws property of the proof.
+var detachedJWSHeader = map[string]any{"alg": "ES256K", "b64": false, "crit": []string{"b64"}}
+
+// EcdsaSecp256k1Signature2019Suite is the Linked Data proof suite of
+// https://w3c-ccg.github.io/lds-ecdsa-secp256k1-2019/. Documents are
+// canonicalized with URDNA2015, resolving contexts with an offline loader.
+type EcdsaSecp256k1Signature2019Suite struct {
+	loader ld.DocumentLoader
+}
+
+func NewEcdsaSecp256k1Signature2019(loader ld.DocumentLoader) EcdsaSecp256k1Signature2019Suite {
+	return EcdsaSecp256k1Signature2019Suite{loader: loader}
+}
+
+func (s EcdsaSecp256k1Signature2019Suite) Matches(proof map[string]any) bool {
+	return proof["type"] == EcdsaSecp256k1Signature2019Type
+}
+
+// Prepare adds the suite context when the document context does not
+// already define the proof terms.
+func (s EcdsaSecp256k1Signature2019Suite) Prepare(document map[string]any, proof map[string]any) {
+	proof["type"] = EcdsaSecp256k1Signature2019Type
+	var contexts []any
+	switch ctx := document["@context"].(type) {
+	case nil:
+	case []any:
+		contexts = append(contexts, ctx...)
+	case []string:
+		for _, c := range ctx {
+			contexts = append(contexts, c)
+		}
+	default:
+		contexts = append(contexts, ctx)
+	}
+	for _, c := range contexts {
+		if c == CredentialsV1Context || c == Secp256k1V1Context {
+			return
+		}
+	}
+	document["@context"] = append(contexts, Secp256k1V1Context)
+}
+
+func (s EcdsaSecp256k1Signature2019Suite) CreateProof(document map[string]any, proof map[string]any, signer Signer) error {
+	verifyData, err := s.verifyData(document, proof)
+	if err != nil {
+		return err
+	}
+	headerBytes, err := json.Marshal(detachedJWSHeader)
+	if err != nil {
+		return err
+	}
+	header := base64url.Encode(headerBytes)
+	sig, err := signer.Sign(append([]byte(header+"."), verifyData...))
+	if err != nil {
+		return err
+	}
+	proof["jws"] = header + ".." + base64url.Encode(sig)
+	return nil
+}
+
+func (s EcdsaSecp256k1Signature2019Suite) VerifyProof(document map[string]any, proof map[string]any, vm saotypes.VerificationMethod) error {
+	jws, _ := proof["jws"].(string)
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return xerrors.New("proof jws should be a detached JWS")
+	}
+	var header struct {
+		Alg  string   `json:"alg"`
+		B64  *bool    `json:"b64"`
+		Crit []string `json:"crit"`
+	}
+	headerBytes, err := base64url.Decode(parts[0])
+	if err != nil {
+		return xerrors.Errorf("decode jws header failed: %v", err)
+	}
+	if err = json.Unmarshal(headerBytes, &header); err != nil {
+		return xerrors.Errorf("parse jws header failed: %v", err)
+	}
+	if header.Alg != "ES256K" || header.B64 == nil || *header.B64 || len(header.Crit) != 1 || header.Crit[0] != "b64" {
+		return xerrors.New("proof jws should be an unencoded ES256K JWS")
+	}
+	sig, err := base64url.Decode(parts[2])
+	if err != nil {
+		return xerrors.Errorf("decode jws signature failed: %v", err)
+	}
+
+	delete(proof, "jws")
+	verifyData, err := s.verifyData(document, proof)
+	if err != nil {
+		return err
+	}
+	return key.VerifySignature(append([]byte(parts[0]+"."), verifyData...), sig, []saotypes.VerificationMethod{vm})
+}
+
+// verifyData is the SHA-256 hash of the canonical proof options followed
+// by the SHA-256 hash of the canonical document.
+func (s EcdsaSecp256k1Signature2019Suite) verifyData(document map[string]any, proof map[string]any) ([]byte, error) {
+	proofOptions := copyDocument(proof)
+	proofOptions["@context"] = document["@context"]
+	canonicalProof, err := s.canonicalize(proofOptions)
+	if err != nil {
+		return nil, err
+	}
+	canonicalDocument, err := s.canonicalize(document)
+	if err != nil {
+		return nil, err
+	}
+	proofHash := sha256.Sum256(canonicalProof)
+	documentHash := sha256.Sum256(canonicalDocument)
+	return append(proofHash[:], documentHash[:]...), nil
+}
+
+func (s EcdsaSecp256k1Signature2019Suite) canonicalize(document map[string]any) ([]byte, error) {
+	// json-gold expects the generic JSON types
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	var input any
+	if err = json.Unmarshal(data, &input); err != nil {
+		return nil, err
+	}
+	options := ld.NewJsonLdOptions("")
+	options.Algorithm = ld.AlgorithmURDNA2015
+	options.Format = "application/n-quads"
+	options.DocumentLoader = s.loader
+	options.SafeMode = true
+	normalized, err := ld.NewJsonLdProcessor().Normalize(input, options)
+	if err != nil {
+		return nil, xerrors.Errorf("canonicalize document failed: %v", err)
+	}
+	return []byte(normalized.(string)), nil
+}
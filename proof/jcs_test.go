@@ -0,0 +1,32 @@
+package proof
+
+import "testing"
+
+func TestCanonicalize(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{"sorts properties", `{"b": 1, "a": {"d": true, "c": null}}`, `{"a":{"c":null,"d":true},"b":1}`},
+		{"sorts by utf-16 code units", `{"\u20ac": 1, "\ufb33": 5, "\ud83d\ude00": 2, "\r": 3, "1": 4}`, "{\"\\r\":3,\"1\":4,\"€\":1,\"😀\":2,\"דּ\":5}"},
+		{"numbers", `[1.0, 1e21, 1e-7, 0.000001, -0, 333333333.33333329, 1E30, 4.50, 2e-3]`, `[1,1e+21,1e-7,0.000001,0,333333333.3333333,1e+30,4.5,0.002]`},
+		{"strings", `"\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/"`, "\"€$\\u000f\\nA'B\\\"\\\\\\\\\\\"/\""},
+		{"literals", `[true, false, null, []]`, `[true,false,null,[]]`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, err := Canonicalize([]byte(c.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != c.out {
+				t.Fatalf("got %s, want %s", out, c.out)
+			}
+		})
+	}
+
+	if _, err := Canonicalize([]byte(`{} {}`)); err == nil {
+		t.Fatal("trailing data accepted")
+	}
+}
@@ -0,0 +1,70 @@
+package proof
+
+import (
+	"bytes"
+	"embed"
+
+	"github.com/piprate/json-gold/ld"
+	"golang.org/x/xerrors"
+)
+
+const (
+	CredentialsV1Context   = "https://www.w3.org/2018/credentials/v1"
+	DidV1Context           = "https://www.w3.org/ns/did/v1"
+	W3idDidV1Context       = "https://w3id.org/did/v1"
+	Secp256k1V1Context     = "https://w3id.org/security/suites/secp256k1-2019/v1"
+	DataIntegrityV1Context = "https://w3id.org/security/data-integrity/v1"
+	StatusList2021Context  = "https://w3id.org/vc/status-list/2021/v1"
+)
+
+//go:embed contexts/*.jsonld
+var contexts embed.FS
+
+var preloadedContexts = map[string]string{
+	CredentialsV1Context:   "contexts/credentials_v1.jsonld",
+	DidV1Context:           "contexts/did_v1.jsonld",
+	W3idDidV1Context:       "contexts/did_v1.jsonld",
+	Secp256k1V1Context:     "contexts/secp256k1_2019_v1.jsonld",
+	DataIntegrityV1Context: "contexts/data_integrity_v1.jsonld",
+	StatusList2021Context:  "contexts/status_list_2021_v1.jsonld",
+}
+
+// DocumentLoader is an offline JSON-LD document loader. It never fetches
+// contexts from the network, every context must be preloaded.
+type DocumentLoader struct {
+	documents map[string]any
+}
+
+// NewDocumentLoader returns a loader preloaded with the DID, VC, status
+// list and the proof suite contexts.
+func NewDocumentLoader() (*DocumentLoader, error) {
+	loader := &DocumentLoader{documents: make(map[string]any)}
+	for url, file := range preloadedContexts {
+		data, err := contexts.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err = loader.AddDocument(url, data); err != nil {
+			return nil, err
+		}
+	}
+	return loader, nil
+}
+
+// AddDocument makes the JSON document available under url.
+func (l *DocumentLoader) AddDocument(url string, data []byte) error {
+	document, err := ld.DocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return xerrors.Errorf("parse %s failed: %v", url, err)
+	}
+	l.documents[url] = document
+	return nil
+}
+
+func (l *DocumentLoader) LoadDocument(url string) (*ld.RemoteDocument, error) {
+	document, ok := l.documents[url]
+	if !ok {
+		return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, "document is not preloaded: "+url)
+	}
+	return &ld.RemoteDocument{DocumentURL: url, Document: document}, nil
+}
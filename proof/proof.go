@@ -0,0 +1,179 @@
+// Package proof creates and verifies Data Integrity (Linked Data) proofs
+// embedded in JSON documents, https://www.w3.org/TR/vc-data-integrity/
+package proof
+
+import (
+	"strings"
+	"time"
+
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"golang.org/x/xerrors"
+)
+
+const (
+	AssertionMethod = "assertionMethod"
+	Authentication  = "authentication"
+)
+
+// Signer is a provider that can sign arbitrary bytes, which proofs need
+// besides JWS, as key.Secp256k1Provider does.
+type Signer interface {
+	saotypes.DidProvider
+	Sign(data []byte) ([]byte, error)
+}
+
+// Suite is a proof suite or Data Integrity cryptosuite.
+type Suite interface {
+	// Matches reports whether the suite handles the proof.
+	Matches(proof map[string]any) bool
+	// Prepare sets the suite specific options on the proof and on the
+	// document before signing.
+	Prepare(document map[string]any, proof map[string]any)
+	// CreateProof signs the document without proof and adds the proof value
+	// to the proof options.
+	CreateProof(document map[string]any, proof map[string]any, signer Signer) error
+	// VerifyProof checks the proof value of the document without proof
+	// against the verification method.
+	VerifyProof(document map[string]any, proof map[string]any, vm saotypes.VerificationMethod) error
+}
+
+type Options struct {
+	// VerificationMethod defaults to the did:key key of the signer.
+	VerificationMethod string
+	// ProofPurpose defaults to assertionMethod.
+	ProofPurpose string
+	// Created defaults to the current time of Clock.
+	Created   string
+	Challenge string
+	Domain    string
+	// Clock defaults to the system clock.
+	Clock saotypes.Clock
+}
+
+// Sign adds a proof created with the suite to a copy of the document.
+func Sign(document map[string]any, signer Signer, suite Suite, options Options) (map[string]any, error) {
+	if signer == nil {
+		return nil, xerrors.New("signer is missing.")
+	}
+	if _, ok := document["proof"]; ok {
+		return nil, xerrors.New("document already has a proof")
+	}
+	unsecured := copyDocument(document)
+
+	vm := options.VerificationMethod
+	if vm == "" {
+		did := signer.Did()
+		if !strings.HasPrefix(did, "did:key:") {
+			return nil, xerrors.New("verification method is missing.")
+		}
+		vm = did + "#" + strings.TrimPrefix(did, "did:key:")
+	}
+	purpose := options.ProofPurpose
+	if purpose == "" {
+		purpose = AssertionMethod
+	}
+	created := options.Created
+	if created == "" {
+		clock := options.Clock
+		if clock == nil {
+			clock = saotypes.SystemClock{}
+		}
+		created = clock.Now().UTC().Format(time.RFC3339)
+	}
+	proof := map[string]any{
+		"created":            created,
+		"verificationMethod": vm,
+		"proofPurpose":       purpose,
+	}
+	if options.Challenge != "" {
+		proof["challenge"] = options.Challenge
+	}
+	if options.Domain != "" {
+		proof["domain"] = options.Domain
+	}
+	suite.Prepare(unsecured, proof)
+
+	if err := suite.CreateProof(unsecured, proof, signer); err != nil {
+		return nil, err
+	}
+	secured := copyDocument(unsecured)
+	secured["proof"] = proof
+	return secured, nil
+}
+
+// Verifier verifies embedded proofs of any of its suites, it implements
+// vc.ProofVerifier.
+type Verifier struct {
+	Suites []Suite
+}
+
+// NewVerifier returns a verifier for all the suites of this package, using
+// the offline document loader for the RDF based ones.
+func NewVerifier() (*Verifier, error) {
+	loader, err := NewDocumentLoader()
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{Suites: []Suite{
+		NewEcdsaJcs2019(),
+		NewEcdsaSecp256k1Signature2019(loader),
+	}}, nil
+}
+
+// VerifyProof verifies the proof of the document and returns its
+// verification method.
+func (v *Verifier) VerifyProof(document map[string]any, resolver saotypes.DidResolver) (string, error) {
+	proof, ok := document["proof"].(map[string]any)
+	if !ok {
+		return "", xerrors.New("document has no single proof")
+	}
+	var suite Suite
+	for _, s := range v.Suites {
+		if s.Matches(proof) {
+			suite = s
+			break
+		}
+	}
+	if suite == nil {
+		return "", xerrors.Errorf("unsupported proof type %v", proof["type"])
+	}
+
+	vmId, _ := proof["verificationMethod"].(string)
+	if vmId == "" {
+		return "", xerrors.New("proof has no verificationMethod")
+	}
+	didResolutionResult := resolver.Resolve(vmId, saotypes.DidResolutionOptions{})
+	if didResolutionResult.DidResolutionMetadata.Error != "" {
+		return "", xerrors.Errorf("resolve %s failed: %s", vmId, didResolutionResult.DidResolutionMetadata.Error)
+	}
+	didDoc := didResolutionResult.DidDocument
+	switch proof["proofPurpose"] {
+	case AssertionMethod:
+		if !didDoc.HasAssertionMethod(vmId) {
+			return "", xerrors.Errorf("%s is not an assertion method", vmId)
+		}
+	case Authentication:
+		if !didDoc.HasAuthentication(vmId) {
+			return "", xerrors.Errorf("%s is not an authentication method", vmId)
+		}
+	default:
+		return "", xerrors.Errorf("unsupported proofPurpose %v", proof["proofPurpose"])
+	}
+	for _, vm := range didDoc.VerificationMethod {
+		if vm.Id == vmId {
+			unsecured := copyDocument(document)
+			delete(unsecured, "proof")
+			return vmId, suite.VerifyProof(unsecured, copyDocument(proof), vm)
+		}
+	}
+	return "", xerrors.Errorf("verification method %s not found", vmId)
+}
+
+// copyDocument returns a shallow copy of the document.
+func copyDocument(document map[string]any) map[string]any {
+	c := make(map[string]any, len(document))
+	for k, v := range document {
+		c[k] = v
+	}
+	return c
+}
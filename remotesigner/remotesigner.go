@@ -0,0 +1,120 @@
+// Package remotesigner speaks to a types.Signer running in another process
+// over JSON-RPC on a Unix socket, so that keys can live in a separate,
+// hardened process. Server exposes any Signer, e.g. an in-memory key as a
+// local stand-in in tests, and Client is the Signer of the calling side.
+package remotesigner
+
+import (
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"golang.org/x/xerrors"
+)
+
+// ServiceName prefixes the RPC methods, e.g. Signer.Sign.
+const ServiceName = "Signer"
+
+type KeyArgs struct{}
+
+type KeyReply struct {
+	PublicKey []byte `json:"publicKey"`
+	Algorithm string `json:"algorithm"`
+}
+
+type SignArgs struct {
+	Data []byte `json:"data"`
+}
+
+type SignReply struct {
+	Signature []byte `json:"signature"`
+}
+
+type service struct {
+	signer saotypes.Signer
+}
+
+func (s *service) Key(_ KeyArgs, reply *KeyReply) error {
+	reply.PublicKey = s.signer.PublicKey()
+	reply.Algorithm = s.signer.Algorithm()
+	return nil
+}
+
+func (s *service) Sign(args SignArgs, reply *SignReply) error {
+	sig, err := s.signer.Sign(args.Data)
+	if err != nil {
+		return err
+	}
+	reply.Signature = sig
+	return nil
+}
+
+// Server serves a Signer.
+type Server struct {
+	rpc *rpc.Server
+}
+
+func NewServer(signer saotypes.Signer) (*Server, error) {
+	if signer == nil {
+		return nil, xerrors.New("signer is missing.")
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName(ServiceName, &service{signer: signer}); err != nil {
+		return nil, err
+	}
+	return &Server{rpc: server}, nil
+}
+
+// Serve accepts connections on listener until it is closed.
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.rpc.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// Client is a Signer backed by a Server.
+type Client struct {
+	rpc       *rpc.Client
+	publicKey []byte
+	algorithm string
+}
+
+// Dial connects to the server listening on the Unix socket at path and
+// fetches its public key.
+func Dial(path string) (*Client, error) {
+	client, err := jsonrpc.Dial("unix", path)
+	if err != nil {
+		return nil, xerrors.Errorf("connect to signer failed: %v", err)
+	}
+	var reply KeyReply
+	if err = client.Call(ServiceName+".Key", KeyArgs{}, &reply); err != nil {
+		client.Close()
+		return nil, xerrors.Errorf("fetch signer key failed: %v", err)
+	}
+	return &Client{rpc: client, publicKey: reply.PublicKey, algorithm: reply.Algorithm}, nil
+}
+
+func (c *Client) PublicKey() []byte {
+	return c.publicKey
+}
+
+func (c *Client) Algorithm() string {
+	return c.algorithm
+}
+
+func (c *Client) Sign(data []byte) ([]byte, error) {
+	var reply SignReply
+	if err := c.rpc.Call(ServiceName+".Sign", SignArgs{Data: data}, &reply); err != nil {
+		return nil, xerrors.Errorf("remote sign failed: %v", err)
+	}
+	return reply.Signature, nil
+}
+
+func (c *Client) Close() error {
+	return c.rpc.Close()
+}
@@ -0,0 +1,99 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"golang.org/x/xerrors"
+)
+
+// FileCache is a ReplayCache persisted as a JSON file of key to expiry unix
+// time, so used values survive restarts. The file is rewritten on every
+// use and must not be shared between processes.
+type FileCache struct {
+	mu    sync.Mutex
+	path  string
+	clock saotypes.Clock
+}
+
+// NewFileCache returns a cache stored at path, the file is created on first
+// use. clock defaults to the system clock.
+func NewFileCache(path string, clock saotypes.Clock) (*FileCache, error) {
+	if path == "" {
+		return nil, xerrors.New("replay cache path is missing.")
+	}
+	if clock == nil {
+		clock = saotypes.SystemClock{}
+	}
+	return &FileCache{path: path, clock: clock}, nil
+}
+
+// Path returns the file the cache is stored in.
+func (f *FileCache) Path() string {
+	return f.path
+}
+
+func (f *FileCache) Use(key string, expiry time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entries, err := f.load()
+	if err != nil {
+		return false, err
+	}
+	if !use(entries, f.clock.Now(), key, expiry) {
+		return false, nil
+	}
+	return true, f.store(entries)
+}
+
+func (f *FileCache) load() (map[string]time.Time, error) {
+	entries := make(map[string]time.Time)
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("read replay cache failed: %v", err)
+	}
+	var stored map[string]int64
+	if err = json.Unmarshal(data, &stored); err != nil {
+		return nil, xerrors.Errorf("parse replay cache failed: %v", err)
+	}
+	for k, e := range stored {
+		entries[k] = time.Unix(e, 0)
+	}
+	return entries, nil
+}
+
+func (f *FileCache) store(entries map[string]time.Time) error {
+	stored := make(map[string]int64, len(entries))
+	for k, e := range entries {
+		stored[k] = e.Unix()
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	// write then rename so a crash never leaves a truncated cache
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return xerrors.Errorf("write replay cache failed: %v", err)
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return xerrors.Errorf("write replay cache failed: %v", err)
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return xerrors.Errorf("write replay cache failed: %v", err)
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
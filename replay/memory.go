@@ -0,0 +1,44 @@
+// Package replay implements types.ReplayCache in memory and on disk.
+package replay
+
+import (
+	"sync"
+	"time"
+
+	saotypes "github.com/SaoNetwork/sao-did/types"
+)
+
+// MemoryCache is a ReplayCache held in memory, expired keys are dropped
+// when new keys are used.
+type MemoryCache struct {
+	mu      sync.Mutex
+	clock   saotypes.Clock
+	entries map[string]time.Time
+}
+
+// NewMemoryCache returns an empty cache, clock defaults to the system clock.
+func NewMemoryCache(clock saotypes.Clock) *MemoryCache {
+	if clock == nil {
+		clock = saotypes.SystemClock{}
+	}
+	return &MemoryCache{clock: clock, entries: make(map[string]time.Time)}
+}
+
+func (m *MemoryCache) Use(key string, expiry time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return use(m.entries, m.clock.Now(), key, expiry), nil
+}
+
+func use(entries map[string]time.Time, now time.Time, key string, expiry time.Time) bool {
+	for k, e := range entries {
+		if !now.Before(e) {
+			delete(entries, k)
+		}
+	}
+	if _, ok := entries[key]; ok {
+		return false
+	}
+	entries[key] = expiry
+	return true
+}
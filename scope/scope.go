@@ -0,0 +1,163 @@
+// Package scope matches SAO data paths against granted path patterns.
+//
+// Paths are slash separated. A pattern grants the path it names and every
+// path below it, its segments may use path.Match wildcards such as "*" or
+// "note-?" and a "**" segment grants everything below. "/" grants all, an
+// empty pattern or one with empty, "." or ".." segments grants nothing.
+package scope
+
+import (
+	"path"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// OutOfScopeError is returned when a path is not granted.
+type OutOfScopeError struct {
+	Path    string
+	Granted []string
+}
+
+func (e *OutOfScopeError) Error() string {
+	return "path " + e.Path + " is out of scope, granted: " + strings.Join(e.Granted, ", ")
+}
+
+// Match reports whether pattern grants p.
+func Match(pattern, p string) bool {
+	segments, err := parse(pattern)
+	return err == nil && covers(segments, split(p))
+}
+
+// Covers reports whether any of the granted patterns grants p.
+func Covers(granted []string, p string) bool {
+	target := split(p)
+	for _, pattern := range granted {
+		if segments, err := parse(pattern); err == nil && covers(segments, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error unless every pattern is a valid, non empty
+// pattern without empty, "." or ".." segments.
+func Validate(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := parse(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subset reports whether every requested pattern is granted, so that a
+// scope can only be narrowed when it is delegated.
+func Subset(requested, granted []string) bool {
+	grantedSegments := make([][]string, 0, len(granted))
+	for _, pattern := range granted {
+		if segments, err := parse(pattern); err == nil {
+			grantedSegments = append(grantedSegments, segments)
+		}
+	}
+	for _, pattern := range requested {
+		segments, err := parse(pattern)
+		if err != nil {
+			return false
+		}
+		ok := false
+		for _, g := range grantedSegments {
+			if within(segments, g) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Authorize returns an *OutOfScopeError unless a granted pattern grants
+// the resource path.
+func Authorize(granted []string, resource string) error {
+	if resource == "" {
+		return xerrors.New("resource path is missing.")
+	}
+	if !Covers(granted, resource) {
+		return &OutOfScopeError{Path: resource, Granted: granted}
+	}
+	return nil
+}
+
+// split cleans p, resolving "." and "..", and returns its segments.
+func split(p string) []string {
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return nil
+	}
+	return strings.Split(p[1:], "/")
+}
+
+// parse validates pattern and returns its segments, a single trailing
+// slash is allowed. Patterns are not cleaned, "." and ".." are rejected.
+func parse(pattern string) ([]string, error) {
+	if pattern == "" {
+		return nil, xerrors.New("path pattern is empty")
+	}
+	if strings.Contains(pattern, "//") {
+		return nil, xerrors.Errorf("path pattern %s has an empty segment", pattern)
+	}
+	trimmed := strings.TrimSuffix(strings.TrimPrefix(pattern, "/"), "/")
+	if trimmed == "" {
+		return nil, nil
+	}
+	segments := strings.Split(trimmed, "/")
+	for _, seg := range segments {
+		if seg == "." || seg == ".." {
+			return nil, xerrors.Errorf("path pattern %s has a relative segment", pattern)
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return nil, xerrors.Errorf("invalid path pattern %s: %v", pattern, err)
+		}
+	}
+	return segments, nil
+}
+
+func covers(pattern, target []string) bool {
+	for i, seg := range pattern {
+		if seg == "**" {
+			return true
+		}
+		if i >= len(target) || target[i] == "**" {
+			return false
+		}
+		if ok, err := path.Match(seg, target[i]); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// within reports whether the granted pattern grants everything the
+// requested pattern does. A requested wildcard segment is only granted by
+// the same segment, "*" or "**".
+func within(requested, granted []string) bool {
+	for i, seg := range granted {
+		if seg == "**" {
+			return true
+		}
+		if i >= len(requested) || requested[i] == "**" {
+			return false
+		}
+		if strings.ContainsAny(requested[i], `*?[\`) {
+			if seg != requested[i] && seg != "*" {
+				return false
+			}
+		} else if ok, err := path.Match(seg, requested[i]); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
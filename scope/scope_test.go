@@ -0,0 +1,80 @@
+package scope
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		match         bool
+	}{
+		{"/", "/models/notes", true},
+		{"/models/notes", "/models/notes", true},
+		{"/models/notes", "/models/notes/1", true},
+		{"/models/notes", "/models/notes-archive", false},
+		{"/models/notes/", "/models/notes/1", true},
+		{"/models/*/public", "/models/notes/public/1", true},
+		{"/models/*/public", "/models/notes/private", false},
+		{"/models/note-?", "/models/note-a", true},
+		{"/models/**", "/models/a/b/c", true},
+		{"/models/notes", "/models/notes/../secrets", false},
+		{"/models/*", "/models/**", false},
+		{"/models/[", "/models/[", false},
+		{"", "/anything", false},
+		{"/models//notes", "/models/notes", false},
+	}
+	for _, c := range cases {
+		if got := Match(c.pattern, c.path); got != c.match {
+			t.Errorf("Match(%q, %q) = %v, want %v", c.pattern, c.path, got, c.match)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	granted := []string{"/models/notes", "/files/*/shared"}
+	if err := Authorize(granted, "/files/alice/shared/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	var scopeErr *OutOfScopeError
+	if err := Authorize(granted, "/files/alice/private"); !errors.As(err, &scopeErr) {
+		t.Fatalf("expected OutOfScopeError, got %v", err)
+	}
+	if !Subset([]string{"/models/notes/1", "/files/*/shared"}, granted) {
+		t.Fatal("narrower scope not a subset")
+	}
+	if Subset([]string{"/models"}, granted) {
+		t.Fatal("wider scope is a subset")
+	}
+	for _, c := range []struct{ requested, granted string }{
+		{"/notes/*", "/notes/?"},
+		{"/notes/n*", "/notes/n?"},
+		{"/notes/**", "/notes/*"},
+		{"/notes/..", "/notes"},
+	} {
+		if Subset([]string{c.requested}, []string{c.granted}) {
+			t.Errorf("Subset(%q, %q) accepted a wider scope", c.requested, c.granted)
+		}
+	}
+	if !Subset([]string{"/notes/n?", "/notes/*/a", "/notes/x/**"}, []string{"/notes/*"}) {
+		t.Fatal("narrower wildcard scope not a subset")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate([]string{"/", "/models/*", "/models/notes/"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, pattern := range []string{"", "//", "/models//notes", "/models/[", "/notes/..", "/notes/./a", ".."} {
+		if err := Validate([]string{pattern}); err == nil {
+			t.Errorf("Validate(%q) accepted", pattern)
+		}
+	}
+	if err := Authorize([]string{""}, "/anything"); err == nil {
+		t.Fatal("empty pattern authorized /anything")
+	}
+	if Covers([]string{"/notes/.."}, "/secret") {
+		t.Fatal("relative pattern granted /secret")
+	}
+}
@@ -0,0 +1,69 @@
+package sdjwt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+
+	"github.com/dvsekhvalnov/jose2go/base64url"
+	"golang.org/x/xerrors"
+)
+
+// Disclosure reveals one selectively disclosable claim, it is the base64url
+// encoded JSON array [salt, name, value].
+type Disclosure struct {
+	Salt    string
+	Name    string
+	Value   any
+	Encoded string
+}
+
+func newDisclosure(name string, value any) (Disclosure, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return Disclosure{}, err
+	}
+	d := Disclosure{Salt: base64url.Encode(salt), Name: name, Value: value}
+	bytes, err := json.Marshal([]any{d.Salt, d.Name, d.Value})
+	if err != nil {
+		return Disclosure{}, err
+	}
+	d.Encoded = base64url.Encode(bytes)
+	return d, nil
+}
+
+func parseDisclosure(encoded string) (Disclosure, error) {
+	bytes, err := base64url.Decode(encoded)
+	if err != nil {
+		return Disclosure{}, xerrors.Errorf("decode disclosure failed: %v", err)
+	}
+	var parts []any
+	if err = json.Unmarshal(bytes, &parts); err != nil {
+		return Disclosure{}, xerrors.Errorf("parse disclosure failed: %v", err)
+	}
+	if len(parts) != 3 {
+		return Disclosure{}, xerrors.New("disclosure should be [salt, name, value]")
+	}
+	salt, ok := parts[0].(string)
+	if !ok {
+		return Disclosure{}, xerrors.New("disclosure salt should be a string")
+	}
+	name, ok := parts[1].(string)
+	if !ok {
+		return Disclosure{}, xerrors.New("disclosure claim name should be a string")
+	}
+	if name == sdClaim || name == "..." {
+		return Disclosure{}, xerrors.Errorf("disclosure must not disclose %s", name)
+	}
+	return Disclosure{Salt: salt, Name: name, Value: parts[2], Encoded: encoded}, nil
+}
+
+// Digest is the base64url SHA-256 digest of the encoded disclosure.
+func (d Disclosure) Digest() string {
+	return digest(d.Encoded)
+}
+
+func digest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return base64url.Encode(sum[:])
+}
@@ -0,0 +1,346 @@
+// Package sdjwt implements Selective Disclosure for JWTs (SD-JWT),
+// https://datatracker.ietf.org/doc/draft-ietf-oauth-selective-disclosure-jwt/,
+// with issuers and holders identified by DIDs.
+package sdjwt
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	did "github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/jwt"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"github.com/SaoNetwork/sao-did/util"
+	"golang.org/x/xerrors"
+)
+
+const (
+	separator   = "~"
+	sdClaim     = "_sd"
+	sdAlgClaim  = "_sd_alg"
+	sdAlgSha256 = "sha-256"
+
+	keyBindingTyp = "kb+jwt"
+
+	defaultKeyBindingLifetime = 600 * time.Second
+)
+
+type IssueOptions struct {
+	// Holder is the DID the holder proves possession of with a key binding
+	// JWT, it is bound with the cnf claim.
+	Holder string
+	// Disclosable lists the top level claims that are selectively disclosable.
+	Disclosable []string
+	// Clock sets the default iat, defaults to the system clock.
+	Clock saotypes.Clock
+}
+
+// SdJwt is a parsed SD-JWT: the issuer signed JWT, the disclosures and the
+// optional key binding JWT.
+type SdJwt struct {
+	Jwt         string
+	Disclosures []Disclosure
+	KeyBinding  string
+}
+
+// Parse splits a <JWT>~<Disclosure>~...~<KB-JWT> string.
+func Parse(sdJwt string) (SdJwt, error) {
+	parts := strings.Split(sdJwt, separator)
+	if len(parts) < 2 {
+		return SdJwt{}, xerrors.New("sd-jwt should be separated by ~")
+	}
+	result := SdJwt{Jwt: parts[0], KeyBinding: parts[len(parts)-1]}
+	for _, encoded := range parts[1 : len(parts)-1] {
+		d, err := parseDisclosure(encoded)
+		if err != nil {
+			return SdJwt{}, err
+		}
+		result.Disclosures = append(result.Disclosures, d)
+	}
+	return result, nil
+}
+
+// String serializes the SD-JWT.
+func (s SdJwt) String() string {
+	var b strings.Builder
+	b.WriteString(s.Jwt)
+	b.WriteString(separator)
+	for _, d := range s.Disclosures {
+		b.WriteString(d.Encoded)
+		b.WriteString(separator)
+	}
+	b.WriteString(s.KeyBinding)
+	return b.String()
+}
+
+// Issue signs the claims as an SD-JWT with the provider. Each disclosable
+// claim is replaced by the digest of a salted disclosure, the holder
+// receives all disclosures.
+func Issue(provider saotypes.DidProvider, claims map[string]any, options IssueOptions) (string, error) {
+	if provider == nil {
+		return "", xerrors.New("provider is missing.")
+	}
+	payload := make(map[string]any, len(claims))
+	for k, v := range claims {
+		payload[k] = v
+	}
+	if _, ok := payload[sdClaim]; ok {
+		return "", xerrors.Errorf("%s is a reserved claim", sdClaim)
+	}
+	payload["iss"] = provider.Did()
+	if _, ok := payload["iat"]; !ok {
+		payload["iat"] = now(options.Clock).Unix()
+	}
+	if options.Holder != "" {
+		payload["cnf"] = map[string]any{"kid": options.Holder}
+	}
+
+	var disclosures []Disclosure
+	var digests []string
+	for _, name := range options.Disclosable {
+		value, ok := payload[name]
+		if !ok {
+			return "", xerrors.Errorf("disclosable claim %s not found", name)
+		}
+		d, err := newDisclosure(name, value)
+		if err != nil {
+			return "", err
+		}
+		delete(payload, name)
+		disclosures = append(disclosures, d)
+		digests = append(digests, d.Digest())
+	}
+	if len(digests) > 0 {
+		// sorting hides the original order of the claims
+		sort.Strings(digests)
+		payload[sdClaim] = digests
+		payload[sdAlgClaim] = sdAlgSha256
+	}
+
+	token, err := jwt.Sign(provider, payload, nil)
+	if err != nil {
+		return "", err
+	}
+	return SdJwt{Jwt: token, Disclosures: disclosures}.String(), nil
+}
+
+type PresentOptions struct {
+	// Disclose lists the names of the claims to reveal.
+	Disclose []string
+	// Nonce and Aud are the verifier's challenge and identifier, they are
+	// signed in the key binding JWT.
+	Nonce string
+	Aud   string
+	// Clock sets the iat of the key binding JWT, defaults to the system clock.
+	Clock saotypes.Clock
+}
+
+// Present keeps only the disclosures of the selected claims and appends a
+// key binding JWT signed by the holder.
+func Present(holder saotypes.DidProvider, sdJwt string, options PresentOptions) (string, error) {
+	if holder == nil {
+		return "", xerrors.New("provider is missing.")
+	}
+	issued, err := Parse(sdJwt)
+	if err != nil {
+		return "", err
+	}
+	presentation := SdJwt{Jwt: issued.Jwt}
+	for _, d := range issued.Disclosures {
+		for _, name := range options.Disclose {
+			if d.Name == name {
+				presentation.Disclosures = append(presentation.Disclosures, d)
+				break
+			}
+		}
+	}
+	kb, err := jwt.Sign(holder, map[string]any{
+		"iat":     now(options.Clock).Unix(),
+		"aud":     options.Aud,
+		"nonce":   options.Nonce,
+		"sd_hash": digest(presentation.String()),
+	}, map[string]any{"typ": keyBindingTyp})
+	if err != nil {
+		return "", err
+	}
+	presentation.KeyBinding = kb
+	return presentation.String(), nil
+}
+
+type VerifyOptions struct {
+	// Clock defaults to the system clock.
+	Clock saotypes.Clock
+	// RequireKeyBinding rejects presentations without key binding JWT.
+	RequireKeyBinding bool
+	// Nonce and Aud are compared with the key binding JWT.
+	Nonce string
+	Aud   string
+	// KeyBindingLifetime bounds the age of the key binding JWT, defaults to
+	// 600 seconds.
+	KeyBindingLifetime time.Duration
+}
+
+type Result struct {
+	Issuer string
+	Holder string
+	// Claims are the always visible claims plus the disclosed ones.
+	Claims map[string]any
+}
+
+// Verify checks the issuer signature, reconstructs the disclosed claims and
+// verifies the key binding JWT.
+func Verify(sdJwt string, resolver saotypes.DidResolver, options VerifyOptions) (Result, error) {
+	if resolver == nil {
+		return Result{}, xerrors.New("resolver is missing.")
+	}
+	clock := options.Clock
+	if clock == nil {
+		clock = saotypes.SystemClock{}
+	}
+	presentation, err := Parse(sdJwt)
+	if err != nil {
+		return Result{}, err
+	}
+
+	dm := did.NewDidManager(nil, resolver)
+	dm.Clock = clock
+	var claims map[string]any
+	jws, err := jwt.Decode(presentation.Jwt, &claims)
+	if err != nil {
+		return Result{}, err
+	}
+	kid, err := dm.VerifyJWS(jws)
+	if err != nil {
+		return Result{}, xerrors.Errorf("verify issuer signature failed: %v", err)
+	}
+	issuer, _ := claims["iss"].(string)
+	if signer, err := util.KidToDid(kid); err != nil || signer != issuer {
+		return Result{}, xerrors.New("sd-jwt is not signed by its issuer")
+	}
+	if err = checkTimes(claims, clock.Now()); err != nil {
+		return Result{}, err
+	}
+	if err = disclose(claims, presentation.Disclosures); err != nil {
+		return Result{}, err
+	}
+
+	result := Result{Issuer: issuer, Claims: claims}
+	if cnf, ok := claims["cnf"].(map[string]any); ok {
+		result.Holder, _ = cnf["kid"].(string)
+	}
+	if presentation.KeyBinding == "" {
+		if options.RequireKeyBinding {
+			return Result{}, xerrors.New("key binding jwt is missing")
+		}
+		return result, nil
+	}
+	if result.Holder == "" {
+		return Result{}, xerrors.New("sd-jwt has no holder to bind")
+	}
+	err = verifyKeyBinding(dm, presentation, result.Holder, clock.Now(), options)
+	if err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+// disclose replaces the digests of the disclosures with the claims, every
+// digest can be disclosed once.
+func disclose(claims map[string]any, disclosures []Disclosure) error {
+	digests := map[string]bool{}
+	if sd, ok := claims[sdClaim]; ok {
+		if claims[sdAlgClaim] != sdAlgSha256 {
+			return xerrors.Errorf("unsupported %s %v", sdAlgClaim, claims[sdAlgClaim])
+		}
+		list, ok := sd.([]any)
+		if !ok {
+			return xerrors.Errorf("%s should be an array", sdClaim)
+		}
+		for _, d := range list {
+			s, ok := d.(string)
+			if !ok {
+				return xerrors.Errorf("%s should contain strings", sdClaim)
+			}
+			digests[s] = true
+		}
+	}
+	delete(claims, sdClaim)
+	delete(claims, sdAlgClaim)
+
+	for _, d := range disclosures {
+		dig := d.Digest()
+		if !digests[dig] {
+			return xerrors.Errorf("disclosure of %s is not referenced by the sd-jwt", d.Name)
+		}
+		delete(digests, dig)
+		if _, ok := claims[d.Name]; ok {
+			return xerrors.Errorf("disclosed claim %s already exists", d.Name)
+		}
+		claims[d.Name] = d.Value
+	}
+	return nil
+}
+
+func verifyKeyBinding(dm did.DidManager, presentation SdJwt, holder string, now time.Time, options VerifyOptions) error {
+	var kb struct {
+		Iat    int64  `json:"iat"`
+		Aud    string `json:"aud"`
+		Nonce  string `json:"nonce"`
+		SdHash string `json:"sd_hash"`
+	}
+	jws, err := jwt.Decode(presentation.KeyBinding, &kb)
+	if err != nil {
+		return xerrors.Errorf("key binding jwt: %v", err)
+	}
+	header, err := jws.Signatures[0].GetProtectedHeader()
+	if err != nil {
+		return xerrors.Errorf("key binding jwt: %v", err)
+	}
+	if header.Typ != keyBindingTyp {
+		return xerrors.Errorf("key binding jwt typ should be %s", keyBindingTyp)
+	}
+	kid, err := dm.VerifyJWS(jws)
+	if err != nil {
+		return xerrors.Errorf("verify key binding signature failed: %v", err)
+	}
+	if signer, err := util.KidToDid(kid); err != nil || signer != holder {
+		return xerrors.New("key binding jwt is not signed by the holder")
+	}
+	presentation.KeyBinding = ""
+	if kb.SdHash != digest(presentation.String()) {
+		return xerrors.New("key binding jwt sd_hash mismatch")
+	}
+	if kb.Nonce != options.Nonce {
+		return xerrors.New("key binding jwt has a wrong nonce")
+	}
+	if kb.Aud != options.Aud {
+		return xerrors.New("key binding jwt has a wrong aud")
+	}
+	lifetime := options.KeyBindingLifetime
+	if lifetime == 0 {
+		lifetime = defaultKeyBindingLifetime
+	}
+	issued := time.Unix(kb.Iat, 0)
+	if issued.After(now) || now.Sub(issued) > lifetime {
+		return xerrors.New("key binding jwt is expired")
+	}
+	return nil
+}
+
+func now(clock saotypes.Clock) time.Time {
+	if clock == nil {
+		clock = saotypes.SystemClock{}
+	}
+	return clock.Now()
+}
+
+func checkTimes(claims map[string]any, now time.Time) error {
+	if exp, ok := claims["exp"].(float64); ok && now.Unix() >= int64(exp) {
+		return xerrors.New("sd-jwt is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Unix() < int64(nbf) {
+		return xerrors.New("sd-jwt is not valid yet")
+	}
+	return nil
+}
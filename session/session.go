@@ -0,0 +1,158 @@
+// Package session implements delegated session keys: a parent provider,
+// for a SID or a did:key, signs a time-bounded and path-scoped capability
+// for an ephemeral did:key, which then signs on the parent's behalf.
+// DidManager.VerifyAuthentication and DidManager.VerifySessionJWS accept
+// such signatures while the capability holds, DidManager.VerifyJWS never.
+package session
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"time"
+
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/scope"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"github.com/thanhpk/randstr"
+	"golang.org/x/xerrors"
+)
+
+// DefaultLifetime is the validity of a capability when none is given.
+const DefaultLifetime = 24 * time.Hour
+
+type Options struct {
+	// Paths the session may authenticate for.
+	Paths []string
+	// Lifetime of the capability, DefaultLifetime when zero.
+	Lifetime time.Duration
+	// Clock defaults to the system clock.
+	Clock saotypes.Clock
+}
+
+// Session is a types.DidProvider for the parent DID backed by an ephemeral
+// session key.
+type Session struct {
+	parent     string
+	key        *key.Secp256k1Provider
+	capability saotypes.Capability
+	compact    string
+	clock      saotypes.Clock
+}
+
+// Create generates a session key and has parent delegate to it.
+func Create(parent saotypes.DidProvider, options Options) (*Session, error) {
+	if parent == nil {
+		return nil, xerrors.New("provider is missing.")
+	}
+	if err := scope.Validate(options.Paths); err != nil {
+		return nil, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	sessionKey, err := key.NewSecp256k1Provider(secret)
+	if err != nil {
+		return nil, err
+	}
+	clock := options.Clock
+	if clock == nil {
+		clock = saotypes.SystemClock{}
+	}
+	sessionKey.SetClock(clock)
+	lifetime := options.Lifetime
+	if lifetime == 0 {
+		lifetime = DefaultLifetime
+	}
+	now := clock.Now()
+	capability := saotypes.Capability{
+		Iss:   parent.Did(),
+		Aud:   sessionKey.Did(),
+		Paths: options.Paths,
+		Nonce: randstr.String(16),
+		Iat:   now.Unix(),
+		Exp:   now.Add(lifetime).Unix(),
+	}
+	payload, err := json.Marshal(capability)
+	if err != nil {
+		return nil, err
+	}
+	jws, err := parent.CreateJWS(payload)
+	if err != nil {
+		return nil, xerrors.Errorf("sign capability failed: %v", err)
+	}
+	compact, err := jws.Compact()
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		parent:     capability.Iss,
+		key:        sessionKey,
+		capability: capability,
+		compact:    compact,
+		clock:      clock,
+	}, nil
+}
+
+// Did returns the parent DID the session signs for.
+func (s *Session) Did() string {
+	return s.parent
+}
+
+// SessionDid returns the did:key of the session key.
+func (s *Session) SessionDid() string {
+	return s.key.Did()
+}
+
+func (s *Session) Capability() saotypes.Capability {
+	return s.capability
+}
+
+// IsExpired reports whether the capability has expired.
+func (s *Session) IsExpired() bool {
+	return s.clock.Now().Unix() >= s.capability.Exp
+}
+
+// Authenticate answers an authentication request for the parent DID. The
+// response expires with the capability at the latest.
+func (s *Session) Authenticate(params saotypes.AuthParams) (saotypes.GeneralJWS, error) {
+	if s.IsExpired() {
+		return saotypes.GeneralJWS{}, xerrors.New("session is expired")
+	}
+	lifetime := params.Lifetime
+	if lifetime == 0 {
+		lifetime = saotypes.DefaultAuthLifetime
+	}
+	payload := saotypes.Payload{
+		Did:   s.parent,
+		Aud:   params.Aud,
+		Nonce: params.Nonce,
+		Paths: params.Paths,
+		Exp:   s.clock.Now().Add(lifetime).Unix(),
+		Jti:   randstr.String(16),
+	}
+	if payload.Exp > s.capability.Exp {
+		payload.Exp = s.capability.Exp
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return saotypes.GeneralJWS{}, err
+	}
+	return s.CreateJWS(payloadBytes)
+}
+
+// CreateJWS signs with the session key and embeds the capability in the
+// protected header.
+func (s *Session) CreateJWS(payload []byte, options ...saotypes.CreateJWSOptions) (saotypes.GeneralJWS, error) {
+	var opts saotypes.CreateJWSOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	header := make(map[string]any, len(opts.ProtectedHeader)+1)
+	for k, v := range opts.ProtectedHeader {
+		header[k] = v
+	}
+	header[saotypes.CapabilityHeader] = s.compact
+	opts.ProtectedHeader = header
+	return s.key.CreateJWS(payload, opts)
+}
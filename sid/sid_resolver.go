@@ -3,6 +3,7 @@ package sid
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/multiformats/go-multibase"
 
@@ -23,11 +24,33 @@ type PubKey struct {
 	Value string
 }
 type SidDocument struct {
+	// Sid is the identifier of the SID the version belongs to.
+	Sid       string
 	VersionId string
 	Keys      []*PubKey
+	// Timestamp is the unix time this version was created.
+	Timestamp int64
+	// PrevVersionId is the version this one replaced, empty for the first.
+	PrevVersionId string
+	// NextVersionId is the version replacing this one, empty for the latest.
+	NextVersionId string
+	// Deactivated is set on the latest version of a deactivated SID, at
+	// DeactivationTimestamp unix time.
+	Deactivated           bool
+	DeactivationTimestamp int64
 }
 
-type QueryFunc = func(key string) (*SidDocument, error)
+// VersionSelector selects a version of a SID, the latest when empty.
+type VersionSelector struct {
+	VersionId string
+	// VersionTime selects the version valid at that time. A query function
+	// may return a later version, the resolver walks back from it.
+	VersionTime time.Time
+}
+
+// QueryFunc returns the selected version of the SID sid, nil when there is
+// none.
+type QueryFunc = func(sid string, selector VersionSelector) (*SidDocument, error)
 
 type SidResolver struct {
 	query QueryFunc
@@ -51,22 +74,51 @@ func (s *SidResolver) Resolve(sidUrl string, options saotypes.DidResolutionOptio
 
 	result := saotypes.DidResolutionResult{}
 
-	versionId := getVersionInfo(sid.Query)
+	versionId, versionTime, err := getVersionInfo(sid.Query)
+	if err != nil {
+		return saotypes.InvalidDidResult
+	}
 
-	sidDoc, err := s.query(versionId)
+	sidDoc, err := s.queryVersion(sid.ID, VersionSelector{VersionId: versionId, VersionTime: versionTime})
 	if err != nil {
 		return saotypes.InvalidDidResult
 	}
 
 	if sidDoc == nil {
+		return saotypes.NotFoundResult
+	}
+	if versionId != "" && sidDoc.VersionId != versionId {
 		return saotypes.InvalidDidResult
 	}
+	if !versionTime.IsZero() {
+		sidDoc, err = s.versionAt(sid.ID, sidDoc, versionTime)
+		if err != nil {
+			return saotypes.InvalidDidResult
+		}
+		if sidDoc == nil {
+			return saotypes.NotFoundResult
+		}
+	}
 	//res.SidDocument.
 	result.DidDocument, err = toDidDocument(sidDoc, "did:sid:"+sid.ID)
 	if err != nil {
 		return saotypes.InvalidDidResult
 	}
-	result.DidDocumentMetadata.VersionId = versionId
+	result.DidDocumentMetadata, err = s.metadata(sid.ID, sidDoc)
+	if err != nil {
+		return saotypes.InvalidDidResult
+	}
+	latest := sidDoc
+	if sidDoc.NextVersionId != "" {
+		latest, err = s.queryVersion(sid.ID, VersionSelector{})
+		if err != nil || latest == nil {
+			return saotypes.InvalidDidResult
+		}
+	}
+	if latest.Deactivated {
+		result.DidDocumentMetadata.Deactivated = true
+		result.DidDocumentMetadata.DeactivationTime = formatTime(latest.DeactivationTimestamp)
+	}
 
 	contentType := didJson
 	if options.Accept != "" {
@@ -85,18 +137,109 @@ func (s *SidResolver) Resolve(sidUrl string, options saotypes.DidResolutionOptio
 	return result
 }
 
-func getVersionInfo(query string) string {
-	// version-id was changed to versionId in the latest did-core spec
-	// https://github.com/w3c/did-core/pull/553
+// maxVersions bounds walks through the version history.
+const maxVersions = 10000
+
+// queryVersion queries a version and checks that it belongs to sid.
+func (s *SidResolver) queryVersion(sid string, selector VersionSelector) (*SidDocument, error) {
+	doc, err := s.query(sid, selector)
+	if err != nil || doc == nil {
+		return doc, err
+	}
+	if doc.Sid != sid {
+		return nil, xerrors.Errorf("version %s belongs to sid %s, not %s", doc.VersionId, doc.Sid, sid)
+	}
+	return doc, nil
+}
+
+// version queries a known version of sid, it must exist.
+func (s *SidResolver) version(sid string, versionId string) (*SidDocument, error) {
+	doc, err := s.queryVersion(sid, VersionSelector{VersionId: versionId})
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil || doc.VersionId != versionId {
+		return nil, xerrors.Errorf("version %s is missing", versionId)
+	}
+	return doc, nil
+}
+
+// versionAt walks back from doc to the version valid at t, nil when the
+// SID did not exist yet.
+func (s *SidResolver) versionAt(sid string, doc *SidDocument, t time.Time) (*SidDocument, error) {
+	for i := 0; i < maxVersions; i++ {
+		if doc.Timestamp <= t.Unix() {
+			return doc, nil
+		}
+		if doc.PrevVersionId == "" {
+			return nil, nil
+		}
+		prev, err := s.version(sid, doc.PrevVersionId)
+		if err != nil {
+			return nil, err
+		}
+		doc = prev
+	}
+	return nil, xerrors.New("version history is too long")
+}
+
+// metadata describes doc: Created is the time of the first version,
+// Updated the time of doc and NextUpdate the time of its successor.
+func (s *SidResolver) metadata(sid string, doc *SidDocument) (saotypes.DidDocumentMetadata, error) {
+	metadata := saotypes.DidDocumentMetadata{
+		VersionId:     doc.VersionId,
+		NextVersionId: doc.NextVersionId,
+		Updated:       formatTime(doc.Timestamp),
+	}
+	first := doc
+	for i := 0; first.PrevVersionId != ""; i++ {
+		if i == maxVersions {
+			return metadata, xerrors.New("version history is too long")
+		}
+		prev, err := s.version(sid, first.PrevVersionId)
+		if err != nil {
+			return metadata, err
+		}
+		first = prev
+	}
+	metadata.Created = formatTime(first.Timestamp)
+	if doc.NextVersionId != "" {
+		next, err := s.version(sid, doc.NextVersionId)
+		if err != nil {
+			return metadata, err
+		}
+		metadata.NextUpdate = formatTime(next.Timestamp)
+	}
+	return metadata, nil
+}
+
+func formatTime(unix int64) string {
+	if unix == 0 {
+		return ""
+	}
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
+
+// getVersionInfo returns the versionId and versionTime DID parameters.
+func getVersionInfo(query string) (string, time.Time, error) {
 	var versionId string
+	var versionTime time.Time
 	for _, q := range strings.Split(query, "&") {
-		if strings.Contains(q, "versionId") || strings.Contains(q, "version-id") {
-			versionId = strings.Split(q, "=")[1]
-			break
+		name, value, _ := strings.Cut(q, "=")
+		switch name {
+		// version-id was changed to versionId in the latest did-core spec
+		// https://github.com/w3c/did-core/pull/553
+		case "versionId", "version-id":
+			versionId = value
+		case "versionTime":
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return "", time.Time{}, xerrors.Errorf("invalid versionTime: %v", err)
+			}
+			versionTime = t
 		}
 	}
-
-	return versionId
+	return versionId, versionTime, nil
 }
 
 func toDidDocument(content *SidDocument, did string) (saotypes.DidDocument, error) {
@@ -129,6 +272,7 @@ func toDidDocument(content *SidDocument, did string) (saotypes.DidDocument, erro
 			vm.Type = "EcdsaSecp256k1Signature2019"
 			doc.VerificationMethod = append(doc.VerificationMethod, vm)
 			doc.Authentication = append(doc.Authentication, vm)
+			doc.AssertionMethod = append(doc.AssertionMethod, vm)
 		} else if rawPk[0] == 0xec {
 			// it's x25519
 			vm.Type = "X25519KeyAgreementKey2019"
@@ -0,0 +1,108 @@
+package sidop
+
+import (
+	"github.com/SaoNetwork/sao-did/sid"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"golang.org/x/xerrors"
+)
+
+type DeactivationOptions struct {
+	// Clock sets the timestamp, defaults to the system clock.
+	Clock saotypes.Clock
+}
+
+// Deactivation permanently deactivates a SID, it is signed by a key of the
+// latest version.
+type Deactivation struct {
+	Signed
+	Sid           string `json:"sid"`
+	PrevVersionId string `json:"prevVersionId"`
+	Timestamp     int64  `json:"timestamp"`
+}
+
+// CreateDeactivation builds and signs the deactivation of the SID whose
+// latest version is latest.
+func CreateDeactivation(latest sid.SidDocument, signer saotypes.DidProvider, options DeactivationOptions) (Deactivation, error) {
+	if signer == nil {
+		return Deactivation{}, xerrors.New("provider is missing.")
+	}
+	if latest.NextVersionId != "" {
+		return Deactivation{}, xerrors.Errorf("version %s is not the latest", latest.VersionId)
+	}
+	if latest.Deactivated {
+		return Deactivation{}, xerrors.Errorf("sid %s is already deactivated", latest.Sid)
+	}
+	signerKey, err := sidKey("", signer)
+	if err != nil {
+		return Deactivation{}, err
+	}
+	if !hasKey(latest.Keys, signerKey.Value) {
+		return Deactivation{}, xerrors.Errorf("%s is not a key of sid %s", signer.Did(), latest.Sid)
+	}
+	clock := options.Clock
+	if clock == nil {
+		clock = saotypes.SystemClock{}
+	}
+	timestamp := clock.Now().Unix()
+	if timestamp < latest.Timestamp {
+		return Deactivation{}, xerrors.New("deactivation is older than the latest version")
+	}
+	signed, err := sign(deactivationContent(latest.Sid, latest.VersionId, timestamp), []saotypes.DidProvider{signer})
+	if err != nil {
+		return Deactivation{}, err
+	}
+	return Deactivation{Signed: signed, Sid: latest.Sid, PrevVersionId: latest.VersionId, Timestamp: timestamp}, nil
+}
+
+// VerifyDeactivation checks that deactivation was signed by a key of the
+// latest version.
+func VerifyDeactivation(latest sid.SidDocument, deactivation Deactivation) error {
+	signers, err := deactivation.verify()
+	if err != nil {
+		return err
+	}
+	for _, signer := range signers {
+		if !hasKey(latest.Keys, signer) {
+			return xerrors.Errorf("invalid deactivation: signer %s is not a key of version %s", signer, latest.VersionId)
+		}
+	}
+	if deactivation.Sid != latest.Sid || deactivation.PrevVersionId != latest.VersionId {
+		return xerrors.Errorf("invalid deactivation: not for version %s", latest.VersionId)
+	}
+	if deactivation.Timestamp < latest.Timestamp {
+		return xerrors.New("invalid deactivation: older than the latest version")
+	}
+	if !deactivation.encodes(deactivationContent(deactivation.Sid, deactivation.PrevVersionId, deactivation.Timestamp)) {
+		return xerrors.New("invalid deactivation: fields do not match the signed block")
+	}
+	return nil
+}
+
+// Deactivate verifies deactivation against the last of the versions
+// returned by VerifyHistory and returns a copy of the versions with the
+// last one marked deactivated.
+func Deactivate(versions []sid.SidDocument, deactivation Deactivation) ([]sid.SidDocument, error) {
+	if len(versions) == 0 {
+		return nil, xerrors.New("no version to deactivate")
+	}
+	latest := versions[len(versions)-1]
+	if latest.Deactivated {
+		return nil, xerrors.Errorf("sid %s is already deactivated", latest.Sid)
+	}
+	if err := VerifyDeactivation(latest, deactivation); err != nil {
+		return nil, err
+	}
+	latest.Deactivated = true
+	latest.DeactivationTimestamp = deactivation.Timestamp
+	result := append([]sid.SidDocument{}, versions[:len(versions)-1]...)
+	return append(result, latest), nil
+}
+
+func deactivationContent(id, prev string, timestamp int64) map[string]any {
+	return map[string]any{
+		"sid":         id,
+		"prev":        prev,
+		"deactivated": true,
+		"timestamp":   timestamp,
+	}
+}
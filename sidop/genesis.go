@@ -0,0 +1,127 @@
+// Package sidop creates and verifies the signed operations of a SID: the
+// genesis deriving the SID identifier, key updates and deactivation. Each
+// operation is a dag-cbor block signed as a DagJWS, ready to be submitted
+// to the chain, and can be verified offline.
+package sidop
+
+import (
+	"fmt"
+
+	"github.com/SaoNetwork/sao-did/sid"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"golang.org/x/xerrors"
+)
+
+type GenesisOptions struct {
+	// KeyNames name the keys of the providers, key-0, key-1... by default.
+	KeyNames []string
+	// Clock sets the timestamp, defaults to the system clock.
+	Clock saotypes.Clock
+}
+
+// Genesis is the first version of a SID. The SID identifier and the first
+// version id are the CID of the genesis block, signed by every key.
+type Genesis struct {
+	Signed
+	Document sid.SidDocument `json:"document"`
+}
+
+// CreateGenesis builds and signs the genesis of a SID holding the keys of
+// providers, which must be secp256k1 did:key providers.
+func CreateGenesis(providers []saotypes.DidProvider, options GenesisOptions) (Genesis, error) {
+	if len(providers) == 0 {
+		return Genesis{}, xerrors.New("provider is missing.")
+	}
+	if len(options.KeyNames) != 0 && len(options.KeyNames) != len(providers) {
+		return Genesis{}, xerrors.New("one key name is required per provider")
+	}
+	clock := options.Clock
+	if clock == nil {
+		clock = saotypes.SystemClock{}
+	}
+	var keys []*sid.PubKey
+	for i, provider := range providers {
+		name := fmt.Sprintf("key-%d", i)
+		if len(options.KeyNames) != 0 {
+			name = options.KeyNames[i]
+		}
+		k, err := sidKey(name, provider)
+		if err != nil {
+			return Genesis{}, err
+		}
+		keys = append(keys, k)
+	}
+	if err := validateKeys(keys); err != nil {
+		return Genesis{}, err
+	}
+	timestamp := clock.Now().Unix()
+	signed, err := sign(genesisContent(keys, timestamp), providers)
+	if err != nil {
+		return Genesis{}, err
+	}
+	// the SID identifier is the string form of the genesis CIDv1, base32
+	id := signed.Cid.String()
+	return Genesis{
+		Signed: signed,
+		Document: sid.SidDocument{
+			Sid:       id,
+			VersionId: id,
+			Keys:      keys,
+			Timestamp: timestamp,
+		},
+	}, nil
+}
+
+// VerifyGenesis checks offline that the document is the signed genesis
+// block, that the SID identifier is its CID and that the signers are
+// exactly the keys of the document, each signing once.
+func VerifyGenesis(genesis Genesis) error {
+	signers, err := genesis.verify()
+	if err != nil {
+		return err
+	}
+	doc := genesis.Document
+	id := genesis.Cid.String()
+	if doc.Sid != id || doc.VersionId != id {
+		return xerrors.New("invalid genesis: sid is not the genesis cid")
+	}
+	if doc.PrevVersionId != "" {
+		return xerrors.New("invalid genesis: genesis has no previous version")
+	}
+	if err = validateKeys(doc.Keys); err != nil {
+		return xerrors.Errorf("invalid genesis: %v", err)
+	}
+	if !genesis.encodes(genesisContent(doc.Keys, doc.Timestamp)) {
+		return xerrors.New("invalid genesis: document does not match the signed block")
+	}
+	for _, k := range doc.Keys {
+		if !contains(signers, k.Value) {
+			return xerrors.Errorf("invalid genesis: key %s did not sign", k.Name)
+		}
+	}
+	for _, signer := range signers {
+		if !hasKey(doc.Keys, signer) {
+			return xerrors.Errorf("invalid genesis: signer %s is not a key of the document", signer)
+		}
+	}
+	if len(signers) != len(doc.Keys) {
+		return xerrors.New("invalid genesis: every key must sign exactly once")
+	}
+	return nil
+}
+
+func genesisContent(keys []*sid.PubKey, timestamp int64) map[string]any {
+	return map[string]any{
+		"keys":      encodeKeys(keys),
+		"timestamp": timestamp,
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package sidop
+
+import (
+	"bytes"
+
+	"github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/sid"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"github.com/SaoNetwork/sao-did/util"
+	"github.com/dvsekhvalnov/jose2go/base64url"
+	"github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"golang.org/x/xerrors"
+)
+
+// Signed is a SID operation: a dag-cbor block and a DagJWS over its CID
+// with a signature of each signer.
+type Signed struct {
+	Cid         cid.Cid             `json:"cid"`
+	Jws         saotypes.GeneralJWS `json:"jws"`
+	LinkedBlock []byte              `json:"linkedBlock"`
+}
+
+// sign encodes content as a dag-cbor block and signs its CID with every
+// provider.
+func sign(content map[string]any, providers []saotypes.DidProvider) (Signed, error) {
+	if len(providers) == 0 {
+		return Signed{}, xerrors.New("provider is missing.")
+	}
+	dm := did.NewDidManager(providers[0], nil)
+	result, err := dm.CreateDagJWS(content)
+	if err != nil {
+		return Signed{}, err
+	}
+	jws := saotypes.GeneralJWS{Payload: result.Jws.Payload, Signatures: result.Jws.Signatures}
+	for _, provider := range providers[1:] {
+		signed, err := provider.CreateJWS(result.Jws.Link.Bytes())
+		if err != nil {
+			return Signed{}, err
+		}
+		jws.Signatures = append(jws.Signatures, signed.Signatures...)
+	}
+	return Signed{Cid: *result.Jws.Link, Jws: jws, LinkedBlock: result.LinkedBlock}, nil
+}
+
+// verify checks that the block matches the CID and every signature.
+// Signers are did:key identities, their public keys are returned as
+// multibase keys in signature order.
+func (s Signed) verify() ([]string, error) {
+	if len(s.Jws.Signatures) == 0 {
+		return nil, xerrors.New("invalid operation: no signature")
+	}
+	if s.Jws.Payload != base64url.Encode(s.Cid.Bytes()) {
+		return nil, xerrors.New("invalid operation: jws payload is not the operation cid")
+	}
+	var signers []string
+	for _, signature := range s.Jws.Signatures {
+		dm := did.NewDidManager(nil, key.NewKeyResolver())
+		link := s.Cid
+		kid, err := dm.VerifyDagJWS(saotypes.DagJWSResult{
+			Jws:         saotypes.DagJWS{Payload: s.Jws.Payload, Signatures: []saotypes.JwsSignature{signature}, Link: &link},
+			LinkedBlock: s.LinkedBlock,
+		}, nil)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid operation: %v", err)
+		}
+		signer, err := util.KidToDid(kid)
+		if err != nil {
+			return nil, err
+		}
+		keyType, pubKey, err := key.DecodeDidKey(signer)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid operation: %v", err)
+		}
+		multikey, err := key.EncodeMultikey(keyType, pubKey, false)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, multikey)
+	}
+	return signers, nil
+}
+
+// encodes reports whether the signed block is the dag-cbor encoding of
+// content.
+func (s Signed) encodes(content map[string]any) bool {
+	block, err := cbornode.DumpObject(content)
+	return err == nil && bytes.Equal(block, s.LinkedBlock)
+}
+
+// sidKey returns the SID key of a did:key provider, SIDs only hold
+// secp256k1 signing keys.
+func sidKey(name string, provider saotypes.DidProvider) (*sid.PubKey, error) {
+	keyType, pubKey, err := key.DecodeDidKey(provider.Did())
+	if err != nil {
+		return nil, xerrors.Errorf("provider %s: %v", provider.Did(), err)
+	}
+	if keyType != key.KeyTypeSecp256k1 {
+		return nil, xerrors.Errorf("provider %s: sid keys must be secp256k1", provider.Did())
+	}
+	value, err := key.EncodeMultikey(keyType, pubKey, false)
+	if err != nil {
+		return nil, err
+	}
+	return &sid.PubKey{Name: name, Value: value}, nil
+}
+
+func encodeKeys(keys []*sid.PubKey) []any {
+	encoded := make([]any, len(keys))
+	for i, k := range keys {
+		encoded[i] = map[string]any{"name": k.Name, "value": k.Value}
+	}
+	return encoded
+}
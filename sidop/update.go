@@ -0,0 +1,183 @@
+package sidop
+
+import (
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/sid"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"golang.org/x/xerrors"
+)
+
+// UpdateOptions change the keys of a SID: Replace, when set, replaces all
+// keys, then Remove drops keys by name and Add adds keys.
+type UpdateOptions struct {
+	Replace []*sid.PubKey
+	Remove  []string
+	Add     []*sid.PubKey
+	// Clock sets the timestamp, defaults to the system clock.
+	Clock saotypes.Clock
+}
+
+// Update is a new version of a SID signed by a key of the previous version.
+// Its version id is the CID of the update block.
+type Update struct {
+	Signed
+	Document sid.SidDocument `json:"document"`
+}
+
+// CreateUpdate builds the version following current and signs it with
+// signer, a did:key provider of one of the current keys.
+func CreateUpdate(current sid.SidDocument, signer saotypes.DidProvider, options UpdateOptions) (Update, error) {
+	if signer == nil {
+		return Update{}, xerrors.New("provider is missing.")
+	}
+	signerKey, err := sidKey("", signer)
+	if err != nil {
+		return Update{}, err
+	}
+	if !hasKey(current.Keys, signerKey.Value) {
+		return Update{}, xerrors.Errorf("%s is not a key of sid %s", signer.Did(), current.Sid)
+	}
+	if current.Deactivated {
+		return Update{}, xerrors.Errorf("sid %s is deactivated", current.Sid)
+	}
+	keys, err := applyKeys(current.Keys, options)
+	if err != nil {
+		return Update{}, err
+	}
+	clock := options.Clock
+	if clock == nil {
+		clock = saotypes.SystemClock{}
+	}
+	timestamp := clock.Now().Unix()
+	if timestamp < current.Timestamp {
+		return Update{}, xerrors.New("update is older than the current version")
+	}
+	signed, err := sign(updateContent(current.Sid, current.VersionId, keys, timestamp), []saotypes.DidProvider{signer})
+	if err != nil {
+		return Update{}, err
+	}
+	return Update{
+		Signed: signed,
+		Document: sid.SidDocument{
+			Sid:           current.Sid,
+			VersionId:     signed.Cid.String(),
+			Keys:          keys,
+			Timestamp:     timestamp,
+			PrevVersionId: current.VersionId,
+		},
+	}, nil
+}
+
+// VerifyUpdate checks that update is the signed version following prev and
+// that only keys of prev signed it.
+func VerifyUpdate(prev sid.SidDocument, update Update) error {
+	signers, err := update.verify()
+	if err != nil {
+		return err
+	}
+	for _, signer := range signers {
+		if !hasKey(prev.Keys, signer) {
+			return xerrors.Errorf("invalid update: signer %s is not a key of version %s", signer, prev.VersionId)
+		}
+	}
+	if prev.Deactivated {
+		return xerrors.Errorf("invalid update: sid %s is deactivated", prev.Sid)
+	}
+	doc := update.Document
+	if doc.Deactivated {
+		return xerrors.New("invalid update: deactivation is not an update")
+	}
+	if doc.Sid != prev.Sid || doc.PrevVersionId != prev.VersionId {
+		return xerrors.Errorf("invalid update: not a successor of version %s", prev.VersionId)
+	}
+	if doc.VersionId != update.Cid.String() {
+		return xerrors.New("invalid update: version id is not the update cid")
+	}
+	if doc.Timestamp < prev.Timestamp {
+		return xerrors.New("invalid update: older than the previous version")
+	}
+	if err = validateKeys(doc.Keys); err != nil {
+		return xerrors.Errorf("invalid update: %v", err)
+	}
+	if !update.encodes(updateContent(doc.Sid, doc.PrevVersionId, doc.Keys, doc.Timestamp)) {
+		return xerrors.New("invalid update: document does not match the signed block")
+	}
+	return nil
+}
+
+// VerifyHistory replays the genesis and the updates in order and returns
+// every version, linked to its successor.
+func VerifyHistory(genesis Genesis, updates []Update) ([]sid.SidDocument, error) {
+	if err := VerifyGenesis(genesis); err != nil {
+		return nil, err
+	}
+	versions := []sid.SidDocument{genesis.Document}
+	for i, update := range updates {
+		prev := &versions[len(versions)-1]
+		if err := VerifyUpdate(*prev, update); err != nil {
+			return nil, xerrors.Errorf("update %d: %v", i, err)
+		}
+		prev.NextVersionId = update.Document.VersionId
+		versions = append(versions, update.Document)
+	}
+	return versions, nil
+}
+
+func applyKeys(current []*sid.PubKey, options UpdateOptions) ([]*sid.PubKey, error) {
+	keys := current
+	if options.Replace != nil {
+		keys = options.Replace
+	}
+	var result []*sid.PubKey
+	for _, k := range keys {
+		if k == nil || !contains(options.Remove, k.Name) {
+			result = append(result, k)
+		}
+	}
+	result = append(result, options.Add...)
+	if err := validateKeys(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// validateKeys checks that keys are named secp256k1 public multikeys with
+// distinct names, at least one.
+func validateKeys(keys []*sid.PubKey) error {
+	if len(keys) == 0 {
+		return xerrors.New("a sid needs at least one key")
+	}
+	names := map[string]bool{}
+	for _, k := range keys {
+		if k == nil || k.Name == "" || k.Value == "" {
+			return xerrors.New("sid keys need a name and a value")
+		}
+		keyType, _, private, err := key.DecodeMultikey(k.Value)
+		if err != nil || private || keyType != key.KeyTypeSecp256k1 {
+			return xerrors.Errorf("key %s must be a secp256k1 public multikey", k.Name)
+		}
+		if names[k.Name] {
+			return xerrors.Errorf("key %s already exists", k.Name)
+		}
+		names[k.Name] = true
+	}
+	return nil
+}
+
+func updateContent(id, prev string, keys []*sid.PubKey, timestamp int64) map[string]any {
+	return map[string]any{
+		"sid":       id,
+		"prev":      prev,
+		"keys":      encodeKeys(keys),
+		"timestamp": timestamp,
+	}
+}
+
+func hasKey(keys []*sid.PubKey, value string) bool {
+	for _, k := range keys {
+		if k != nil && k.Value == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SaoNetwork/sao-did/auth"
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/scope"
+	"github.com/SaoNetwork/sao-did/types"
+)
+
+func TestChallengeResponse(t *testing.T) {
+	client, err := key.NewSecp256k1Provider([]byte("remote client secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	attacker, err := key.NewSecp256k1Provider([]byte("attacker secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := auth.NewVerifier("sao-gateway", key.NewKeyResolver())
+
+	challenge := verifier.NewChallenge([]string{"/models"})
+	jws, err := auth.Respond(client, challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a forged response with the seen nonce does not burn the challenge
+	forged, err := auth.Respond(attacker, challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.Signatures = jws.Signatures
+	if _, err = verifier.Verify(forged); err == nil {
+		t.Fatal("forged response accepted")
+	}
+	// the authenticated DID must be exactly the signer's
+	for _, claimed := range []string{"", "did:key:z"} {
+		payload, err := json.Marshal(types.Payload{
+			Did:   claimed,
+			Aud:   challenge.Aud,
+			Nonce: challenge.Nonce,
+			Paths: challenge.Paths,
+			Exp:   time.Now().Add(time.Minute).Unix(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		impostor, err := client.CreateJWS(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = verifier.Verify(impostor); err == nil {
+			t.Fatalf("response for did %q accepted", claimed)
+		}
+	}
+	session, err := verifier.Verify(jws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Did != client.Did() || len(session.Paths) != 1 || session.Paths[0] != "/models" {
+		t.Fatalf("unexpected session %+v", session)
+	}
+	if _, err = verifier.Verify(jws); err == nil {
+		t.Fatal("challenge answered twice")
+	}
+	if err = session.Authorize("/models/notes"); err != nil {
+		t.Fatal(err)
+	}
+	var scopeErr *scope.OutOfScopeError
+	if err = session.Authorize("/files/secret"); !errors.As(err, &scopeErr) {
+		t.Fatalf("expected out of scope error, got %v", err)
+	}
+
+	// a response to another verifier's challenge is unknown here
+	other := auth.NewVerifier("sao-gateway", key.NewKeyResolver())
+	jws, err = auth.Respond(client, other.NewChallenge(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = verifier.Verify(jws); err == nil {
+		t.Fatal("foreign challenge accepted")
+	}
+
+	// only the challenge lifetime is exceeded, the response is still valid
+	issued := time.Now().Add(time.Hour)
+	verifier.Clock = fixedClock{issued}
+	challenge = verifier.NewChallenge(nil)
+	client.SetClock(fixedClock{issued})
+	jws, err = client.Authenticate(types.AuthParams{Aud: challenge.Aud, Nonce: challenge.Nonce, Lifetime: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier.Clock = fixedClock{issued.Add(auth.DefaultChallengeLifetime + time.Minute)}
+	if _, err = verifier.Verify(jws); err == nil {
+		t.Fatal("expired challenge accepted")
+	}
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/dagjose"
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/types"
+)
+
+func TestDagJose(t *testing.T) {
+	provider, err := key.NewSecp256k1Provider([]byte("dag-jose secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm := did.NewDidManager(provider, key.NewKeyResolver())
+	result, err := dm.CreateDagJWS(map[string]any{"model": "profile", "version": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, block, err := dagjose.WrapDagJWS(result.Jws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Type() != dagjose.DagJoseCodec {
+		t.Fatalf("unexpected codec %x", c.Type())
+	}
+	isJWS, err := dagjose.IsDagJWS(block)
+	if err != nil || !isJWS {
+		t.Fatal("block is not a dag-jose jws")
+	}
+	decoded, err := dagjose.DecodeDagJWS(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, result.Jws) {
+		t.Fatalf("round trip mismatch %+v != %+v", decoded, result.Jws)
+	}
+	if _, err = dm.VerifyJWS(types.GeneralJWS{Payload: decoded.Payload, Signatures: decoded.Signatures}); err != nil {
+		t.Fatal(err)
+	}
+
+	jwe := types.DagJWE{
+		Protected:  "eyJlbmMiOiJYQzIwUCJ9",
+		Iv:         "PSWIuAyO8CpevzCL",
+		Ciphertext: "3XqLW28NHP-raqW8vMfIHOzko4N3IRaR",
+		Tag:        "WuC3mI8rZPaV8Xs0GV6W9w",
+		Recipients: []types.JweRecipient{{
+			Header:       map[string]any{"alg": "ECDH-ES+XC20PKW", "kid": "did:key:z6LSfQabSbJzX8WAm1qdQcHCHTzVv8a2u6F7kmzdodfvUCo9#z6LSfQabSbJzX8WAm1qdQcHCHTzVv8a2u6F7kmzdodfvUCo9"},
+			EncryptedKey: "Rx8jI3R6J4wBtOsLrmvdG4KyGGzfYLne",
+		}},
+	}
+	block, err = dagjose.EncodeDagJWE(jwe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decodedJwe, err := dagjose.DecodeDagJWE(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decodedJwe, jwe) {
+		t.Fatalf("round trip mismatch %+v != %+v", decodedJwe, jwe)
+	}
+}
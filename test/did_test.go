@@ -1,9 +1,13 @@
 package test
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"github.com/SaoNetwork/sao-did"
 	"github.com/SaoNetwork/sao-did/key"
 	"github.com/SaoNetwork/sao-did/types"
+	"github.com/dvsekhvalnov/jose2go/base64url"
+	"github.com/ipfs/go-cid"
 	cbornode "github.com/ipfs/go-ipld-cbor"
 	"github.com/multiformats/go-multihash"
 	"testing"
@@ -16,11 +20,15 @@ func NewMockProvider() *MockProvider {
 	return &MockProvider{}
 }
 
+func (m *MockProvider) Did() string {
+	return ""
+}
+
 func (m *MockProvider) Authenticate(params types.AuthParams) (types.GeneralJWS, error) {
 	return types.GeneralJWS{}, nil
 }
 
-func (m *MockProvider) CreateJWS(payload []byte) (types.GeneralJWS, error) {
+func (m *MockProvider) CreateJWS(payload []byte, options ...types.CreateJWSOptions) (types.GeneralJWS, error) {
 	return types.GeneralJWS{Payload: "234", Signatures: []types.JwsSignature{{Protected: "5678", Signature: "4324"}}}, nil
 }
 
@@ -41,3 +49,115 @@ func TestDid(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestVerifyDagJWS(t *testing.T) {
+	provider, err := key.NewSecp256k1Provider([]byte("dag jws secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm := did.NewDidManager(provider, key.NewKeyResolver())
+	result, err := dm.CreateDagJWS(map[string]any{"name": "alice", "age": 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]any
+	kid, err := dm.VerifyDagJWS(result, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded["name"] != "alice" || kid == "" {
+		t.Fatalf("unexpected decoded block %+v", decoded)
+	}
+
+	other, err := cbornode.WrapObject(map[string]any{"name": "mallory", "age": 30}, multihash.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.LinkedBlock = other.RawData()
+	if _, err = dm.VerifyDagJWS(result, nil); err == nil {
+		t.Fatal("dag jws verified with a substituted linked block")
+	}
+}
+
+// TestVerifyLegacyDagJWS verifies a DagJWS created by the first release,
+// whose payload is the base64url CID string encoded again.
+func TestVerifyLegacyDagJWS(t *testing.T) {
+	link, err := cid.Decode("bafyreig7uprjcupgce7e2vv4yfettuqw5bw4txivg7lb7w74y645qopesy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := base64.StdEncoding.DecodeString("oWRuYW1lZWFsaWNl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := types.DagJWSResult{
+		Jws: types.DagJWS{
+			Payload: "QVhFU0lOLWo0cEZSNWhFLVRWYTh3VWs1MGhib2JjbmRGVGZXSDl2OHg3bllPZVNX",
+			Signatures: []types.JwsSignature{{
+				Protected: "eyJraWQiOiJkaWQ6a2V5OnpRM3NocXcyRW5jY3NuODN1dVU4UkpLSkVCdkRXZFNFcDdCWUxKUGNMdUFOamhGQkEjelEzc2hxdzJFbmNjc244M3V1VThSSktKRUJ2RFdkU0VwN0JZTEpQY0x1QU5qaEZCQSIsImFsZyI6IkVTMjU2SyJ9",
+				Signature: "Whn9FyXxJlBWaJ_r6Qp9P1f8bFK4PpKkvGQQ02vLKedrDEss_E0dw22dF1Duh9GZ3cbXgf81l902_v3noDNVMA",
+			}},
+			Link: &link,
+		},
+		LinkedBlock: block,
+	}
+	dm := did.NewDidManager(nil, key.NewKeyResolver())
+	var decoded map[string]any
+	if _, err = dm.VerifyDagJWS(legacy, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["name"] != "alice" {
+		t.Fatalf("unexpected decoded block %+v", decoded)
+	}
+}
+
+func TestCreateDagJWSOptions(t *testing.T) {
+	provider, err := key.NewSecp256k1Provider([]byte("dag jws secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm := did.NewDidManager(provider, key.NewKeyResolver())
+	result, err := dm.CreateDagJWS(map[string]any{"name": "alice", "age": 30}, types.CreateDagJWSOptions{
+		HashFunction:     multihash.BLAKE3,
+		Codec:            cid.DagJSON,
+		EmbedLinkedBlock: true,
+		ProtectedHeader:  map[string]any{"typ": "dag-jws"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result.LinkedBlock) != `{"age":30,"name":"alice"}` {
+		t.Fatalf("unexpected dag-json block %s", result.LinkedBlock)
+	}
+	prefix := result.Jws.Link.Prefix()
+	if prefix.Codec != cid.DagJSON || prefix.MhType != multihash.BLAKE3 {
+		t.Fatalf("unexpected cid prefix %+v", prefix)
+	}
+	headerBytes, err := base64url.Decode(result.Jws.Signatures[0].Protected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var header map[string]any
+	if err = json.Unmarshal(headerBytes, &header); err != nil {
+		t.Fatal(err)
+	}
+	if header["typ"] != "dag-jws" || header["linkedBlock"] == nil {
+		t.Fatalf("unexpected protected header %+v", header)
+	}
+
+	var decoded map[string]any
+	if _, err = dm.VerifyDagJWS(result, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["name"] != "alice" {
+		t.Fatalf("unexpected decoded block %+v", decoded)
+	}
+
+	if result.Jws.Link.Version() != 1 {
+		t.Fatalf("unexpected cid version %d", result.Jws.Link.Version())
+	}
+	if _, err = dm.CreateDagJWS(map[string]any{}, types.CreateDagJWSOptions{Codec: 0x70}); err == nil {
+		t.Fatal("dag-pb linked block accepted")
+	}
+}
@@ -0,0 +1,50 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/hd"
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/types"
+)
+
+func TestMnemonicProviders(t *testing.T) {
+	mnemonic, err := hd.NewMnemonic(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = hd.ValidateMnemonic(mnemonic); err != nil {
+		t.Fatal(err)
+	}
+	if err = hd.ValidateMnemonic(mnemonic + " abandon"); err == nil {
+		t.Fatal("invalid mnemonic accepted")
+	}
+
+	secp, err := hd.NewSecp256k1Provider(mnemonic, "", hd.DefaultSecp256k1Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := hd.NewSecp256k1Provider(mnemonic, "", hd.DefaultSecp256k1Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secp.Did() != again.Did() {
+		t.Fatal("derivation is not deterministic")
+	}
+	ed, err := hd.NewEd25519Provider(mnemonic, "", hd.DefaultEd25519Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, provider := range []types.DidProvider{secp, ed} {
+		dm := did.NewDidManager(provider, key.NewKeyResolver())
+		id, err := dm.Authenticate([]string{"/models"}, "hd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != provider.Did() {
+			t.Fatalf("authenticated %s, want %s", id, provider.Did())
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/types"
+)
+
+func TestJWSSerialization(t *testing.T) {
+	provider, err := key.NewSecp256k1Provider([]byte("jws secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm := did.NewDidManager(provider, key.NewKeyResolver())
+	payload := []byte(`{"path":"/models/1.json","size":1024}`)
+
+	jws, err := provider.CreateJWS(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compact, err := jws.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := types.ParseCompact(compact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dm.VerifyJWS(parsed); err != nil {
+		t.Fatal(err)
+	}
+	flattened, err := jws.Flattened()
+	if err != nil {
+		t.Fatal(err)
+	}
+	general, err := json.Marshal(jws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, serialized := range [][]byte{flattened, general} {
+		parsed, err = types.ParseJSON(serialized)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = dm.VerifyJWS(parsed); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	detached, err := provider.CreateJWS(payload, types.CreateJWSOptions{Detached: true, Unencoded: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compact, err = detached.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err = types.ParseCompact(compact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := parsed.Signatures[0].GetProtectedHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.IsDetached() || !header.IsUnencoded() || header.Crit[0] != "b64" {
+		t.Fatalf("unexpected detached jws %s", compact)
+	}
+	if _, err = dm.VerifyDetachedJWS(parsed, payload); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dm.VerifyDetachedJWS(parsed, []byte(`{"path":"/models/2.json"}`)); err == nil {
+		t.Fatal("detached jws verified with another payload")
+	}
+	if _, err = dm.VerifyJWS(parsed); err == nil {
+		t.Fatal("detached jws verified without payload")
+	}
+	if _, err = dm.VerifyDetachedJWS(parsed, nil); err == nil {
+		t.Fatal("detached jws verified with an empty payload")
+	}
+	if _, err = provider.CreateJWS(nil); err == nil {
+		t.Fatal("jws over an empty payload created")
+	}
+
+	unknownCrit, err := provider.CreateJWS(payload, types.CreateJWSOptions{
+		ProtectedHeader: map[string]any{"crit": []string{"exp"}, "exp": 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dm.VerifyJWS(unknownCrit); err == nil {
+		t.Fatal("jws with an unknown critical header parameter verified")
+	}
+}
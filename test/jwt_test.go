@@ -0,0 +1,61 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SaoNetwork/sao-did/jwt"
+	"github.com/SaoNetwork/sao-did/key"
+)
+
+func TestJWT(t *testing.T) {
+	provider, err := key.NewSecp256k1Provider([]byte("jwt secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuedAt := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	token, err := jwt.Issue(provider, jwt.Claims{
+		Subject:  "did:sid:alice",
+		Audience: jwt.Audience{"gateway.sao.network", "node.sao.network"},
+		Custom:   map[string]any{"scope": "read"},
+	}, jwt.IssueOptions{Clock: fixedClock{issuedAt}, Lifetime: time.Hour, Typ: "JWT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := jwt.ValidateOptions{
+		Clock:          fixedClock{issuedAt.Add(30 * time.Minute)},
+		Audience:       []string{"node.sao.network"},
+		Issuers:        []string{provider.Did()},
+		RequiredClaims: []string{"sub", "jti", "scope"},
+	}
+	claims, err := jwt.Validate(token, key.NewKeyResolver(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Issuer != provider.Did() || claims.Subject != "did:sid:alice" || claims.Custom["scope"] != "read" ||
+		claims.Expiry != issuedAt.Add(time.Hour).Unix() || claims.Id == "" {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	expired := options
+	expired.Clock = fixedClock{issuedAt.Add(time.Hour + 30*time.Second)}
+	if _, err = jwt.Validate(token, key.NewKeyResolver(), expired); err == nil {
+		t.Fatal("expired jwt validated")
+	}
+	expired.Leeway = time.Minute
+	if _, err = jwt.Validate(token, key.NewKeyResolver(), expired); err != nil {
+		t.Fatalf("jwt within leeway rejected: %v", err)
+	}
+
+	wrongAudience := options
+	wrongAudience.Audience = []string{"other.sao.network"}
+	if _, err = jwt.Validate(token, key.NewKeyResolver(), wrongAudience); err == nil {
+		t.Fatal("jwt for another audience validated")
+	}
+	missingClaim := options
+	missingClaim.RequiredClaims = []string{"nonce"}
+	if _, err = jwt.Validate(token, key.NewKeyResolver(), missingClaim); err == nil {
+		t.Fatal("jwt without a required claim validated")
+	}
+}
@@ -0,0 +1,59 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestKeyringProvider(t *testing.T) {
+	for _, backend := range []string{keyring.BackendTest, keyring.BackendFile} {
+		// the file backend prompts for the passphrase, twice when it is new
+		input := strings.NewReader(strings.Repeat("keyring passphrase\n", 8))
+		kr, err := key.NewKeyring("sao", backend, t.TempDir(), input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record, _, err := kr.NewMnemonic("alice", keyring.English, sdk.FullFundraiserPath, "", hd.Secp256k1)
+		if err != nil {
+			t.Fatalf("%s backend: %v", backend, err)
+		}
+		provider, err := key.NewKeyringProvider(kr, record.Name)
+		if err != nil {
+			t.Fatalf("%s backend: %v", backend, err)
+		}
+		pubKey, err := record.GetPubKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := key.EncodeDidKey(key.KeyTypeSecp256k1, pubKey.Bytes())
+		if err != nil || provider.Did() != expected {
+			t.Fatalf("%s backend: did %s, want %s", backend, provider.Did(), expected)
+		}
+
+		signature, err := provider.Sign([]byte("keyring message"))
+		if err != nil {
+			t.Fatalf("%s backend: %v", backend, err)
+		}
+		if !pubKey.VerifySignature([]byte("keyring message"), signature) {
+			t.Fatalf("%s backend: invalid signature", backend)
+		}
+		dm := did.NewDidManager(provider, key.NewKeyResolver())
+		id, err := dm.Authenticate([]string{"/models"}, "keyring")
+		if err != nil {
+			t.Fatalf("%s backend: %v", backend, err)
+		}
+		if id != provider.Did() {
+			t.Fatalf("authenticated %s, want %s", id, provider.Did())
+		}
+
+		if _, err = key.NewKeyringProvider(kr, "bob"); err == nil {
+			t.Fatal("missing keyring record accepted")
+		}
+	}
+}
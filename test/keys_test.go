@@ -0,0 +1,86 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/SaoNetwork/sao-did/key"
+)
+
+func TestKeyFormats(t *testing.T) {
+	for _, keyType := range []string{key.KeyTypeSecp256k1, key.KeyTypeEd25519} {
+		privateKey, err := key.GenerateKey(keyType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicKey, err := key.PublicKey(keyType, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		provider, err := key.NewProviderFromKey(keyType, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		did, err := key.EncodeDidKey(keyType, publicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if did != provider.Did() {
+			t.Fatalf("%s: did %s, provider %s", keyType, did, provider.Did())
+		}
+		decodedType, decodedKey, err := key.DecodeDidKey(did + "#key-1")
+		if err != nil || decodedType != keyType || !bytes.Equal(decodedKey, publicKey) {
+			t.Fatalf("%s: decode did:key failed: %v", keyType, err)
+		}
+
+		multikey, err := key.EncodeMultikey(keyType, privateKey, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decodedType, decodedKey, private, err := key.DecodeMultikey(multikey)
+		if err != nil || decodedType != keyType || !private || !bytes.Equal(decodedKey, privateKey) {
+			t.Fatalf("%s: decode multikey failed: %v", keyType, err)
+		}
+
+		jwk, err := key.PrivateJWK(keyType, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decodedType, jwkPublic, jwkPrivate, err := key.ParseJWK(jwk)
+		if err != nil || decodedType != keyType || !bytes.Equal(jwkPublic, publicKey) || !bytes.Equal(jwkPrivate, privateKey) {
+			t.Fatalf("%s: parse jwk failed: %v", keyType, err)
+		}
+
+		privatePem, err := key.EncodePrivatePEM(keyType, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decodedType, decodedKey, private, err = key.DecodePEM(privatePem)
+		if err != nil || decodedType != keyType || !private || !bytes.Equal(decodedKey, privateKey) {
+			t.Fatalf("%s: decode private pem failed: %v", keyType, err)
+		}
+		publicPem, err := key.EncodePublicPEM(keyType, publicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decodedType, decodedKey, private, err = key.DecodePEM(publicPem)
+		if err != nil || decodedType != keyType || private || !bytes.Equal(decodedKey, publicKey) {
+			t.Fatalf("%s: decode public pem failed: %v", keyType, err)
+		}
+	}
+
+	provider, err := key.NewProviderFromHex(key.KeyTypeSecp256k1, "0x"+"07070707070707070707070707070707"+"07070707070707070707070707070707")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := key.NewSecp256k1ProviderFromKey(make32(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.Did() != expected.Did() {
+		t.Fatal("hex import does not match the raw key")
+	}
+	if _, err = key.NewProviderFromKey(key.KeyTypeSecp256k1, make([]byte, 32)); err == nil {
+		t.Fatal("zero secp256k1 key accepted")
+	}
+}
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/keystore"
+)
+
+func TestKeystore(t *testing.T) {
+	for _, options := range []keystore.Options{
+		{KdfParams: keystore.LightScrypt},
+		{Kdf: keystore.KdfArgon2id, KdfParams: keystore.KdfParams{Time: 1, Memory: 1024, Threads: 1}, Cipher: keystore.CipherAes128Ctr},
+	} {
+		dir := t.TempDir()
+		store, err := keystore.NewStore(dir, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		first, err := store.Generate("first passphrase")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cipher := options.Cipher
+		if cipher == "" {
+			cipher = keystore.CipherAes256Gcm
+		}
+		files, err := os.ReadDir(dir)
+		if err != nil || len(files) != 1 {
+			t.Fatalf("unexpected key files %v: %v", files, err)
+		}
+		content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keyFile keystore.KeyFile
+		if err = json.Unmarshal(content, &keyFile); err != nil || keyFile.Crypto.Cipher != cipher {
+			t.Fatalf("key file not encrypted with %s: %s", cipher, content)
+		}
+		second, err := store.Import(keystore.KeyTypeSecp256k1, make32(7), "second passphrase")
+		if err != nil {
+			t.Fatal(err)
+		}
+		dids, err := store.List()
+		if err != nil || len(dids) != 2 {
+			t.Fatalf("unexpected dids %v: %v", dids, err)
+		}
+
+		if _, err = store.Unlock(first, "second passphrase"); err != keystore.ErrDecrypt {
+			t.Fatalf("expected ErrDecrypt, got %v", err)
+		}
+		provider, err := store.Unlock(second, "second passphrase")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := key.NewSecp256k1ProviderFromKey(make32(7))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if provider.Did() != second || second != expected.Did() {
+			t.Fatalf("unlocked %s, want %s", provider.Did(), expected.Did())
+		}
+		if _, err = store.Import(keystore.KeyTypeSecp256k1, make32(7), "other passphrase"); err == nil {
+			t.Fatal("existing key overwritten")
+		}
+		// key files outside the store or stored under another did are refused
+		secondFile := filepath.Join(dir, strings.ReplaceAll(second, ":", "_")+".json")
+		content, err = os.ReadFile(secondFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outside := filepath.Join(t.TempDir(), "escaped.json")
+		if err = os.WriteFile(outside, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+		rel, err := filepath.Rel(dir, strings.TrimSuffix(outside, ".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = store.Unlock(rel, "second passphrase"); err == nil {
+			t.Fatalf("key file %s outside the store unlocked", rel)
+		}
+		if err = os.WriteFile(filepath.Join(dir, "did_key_zOther.json"), content, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = store.Unlock("did:key:zOther", "second passphrase"); err == nil {
+			t.Fatal("key file of another did unlocked")
+		}
+		if err = os.Remove(filepath.Join(dir, "did_key_zOther.json")); err != nil {
+			t.Fatal(err)
+		}
+		dm := did.NewDidManager(provider, key.NewKeyResolver())
+		if _, err = dm.Authenticate(nil, "keystore"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = store.Delete(first, "first passphrase"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = store.Unlock(first, "first passphrase"); err != keystore.ErrNotFound {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+}
+
+func make32(b byte) []byte {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = b
+	}
+	return key
+}
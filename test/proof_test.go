@@ -0,0 +1,53 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/proof"
+	"github.com/SaoNetwork/sao-did/vc"
+)
+
+func TestDataIntegrityProofs(t *testing.T) {
+	issuer, err := key.NewSecp256k1Provider([]byte("issuer secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader, err := proof.NewDocumentLoader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	suites := []proof.Suite{
+		proof.NewEcdsaJcs2019(),
+		proof.NewEcdsaSecp256k1Signature2019(loader),
+	}
+	clock := fixedClock{time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)}
+	for _, suite := range suites {
+		credential, err := vc.IssueLdpCredential(issuer, vc.Credential{
+			Context: []any{
+				vc.CredentialsContext,
+				map[string]any{"@vocab": "https://sao.network/vocab#"},
+			},
+			IssuanceDate:      "2023-01-01T00:00:00Z",
+			CredentialSubject: map[string]any{"id": "did:sid:alice", "name": "alice"},
+		}, suite, proof.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := vc.VerifyCredential(credential, key.NewKeyResolver(), vc.VerifyOptions{Clock: clock})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Verified || result.Format != vc.FormatLDP || result.Credential.SubjectId() != "did:sid:alice" {
+			t.Fatalf("unexpected result %+v", result)
+		}
+
+		tampered := strings.Replace(credential, `"name":"alice"`, `"name":"mallory"`, 1)
+		result, err = vc.VerifyCredential(tampered, key.NewKeyResolver(), vc.VerifyOptions{Clock: clock})
+		if err == nil || result.Failed().Name != vc.CheckSignature {
+			t.Fatalf("tampered credential verified: %+v", result)
+		}
+	}
+}
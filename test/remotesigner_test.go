@@ -0,0 +1,65 @@
+package test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/remotesigner"
+	"github.com/SaoNetwork/sao-did/types"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	// unix socket paths are limited to about 100 bytes
+	dir, err := os.MkdirTemp("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	secp, err := key.NewSecp256k1Signer(make32(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed, err := key.NewEd25519Signer(make32(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, signer := range []types.Signer{secp, ed} {
+		server, err := remotesigner.NewServer(signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		socket := filepath.Join(dir, signer.Algorithm()+".sock")
+		listener, err := net.Listen("unix", socket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+		go server.Serve(listener)
+
+		client, err := remotesigner.Dial(socket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		provider, err := key.NewProvider(client)
+		if err != nil {
+			t.Fatal(err)
+		}
+		local, err := key.NewProvider(signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if provider.Did() != local.Did() {
+			t.Fatalf("%d: remote did %s, want %s", i, provider.Did(), local.Did())
+		}
+		dm := did.NewDidManager(provider, key.NewKeyResolver())
+		if _, err = dm.Authenticate([]string{"/models"}, "remote"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
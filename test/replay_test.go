@@ -0,0 +1,118 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/jwt"
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/replay"
+	"github.com/SaoNetwork/sao-did/types"
+)
+
+func TestReplayCache(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	memory := replay.NewMemoryCache(fixedClock{now})
+	file, err := replay.NewFileCache(filepath.Join(t.TempDir(), "replay.json"), fixedClock{now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cache := range []types.ReplayCache{memory, file} {
+		if fresh, err := cache.Use("a", now.Add(time.Minute)); err != nil || !fresh {
+			t.Fatalf("first use rejected: %v", err)
+		}
+		if fresh, _ := cache.Use("a", now.Add(time.Minute)); fresh {
+			t.Fatal("second use accepted")
+		}
+		// an expired key can be used again
+		if fresh, _ := cache.Use("b", now); !fresh {
+			t.Fatal("first use rejected")
+		}
+		if fresh, _ := cache.Use("b", now); !fresh {
+			t.Fatal("expired key rejected")
+		}
+	}
+
+	reopened, err := replay.NewFileCache(file.Path(), fixedClock{now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh, _ := reopened.Use("a", now.Add(time.Minute)); fresh {
+		t.Fatal("used key forgotten after reopening the file")
+	}
+}
+
+func TestVerifyAuthenticationReplay(t *testing.T) {
+	provider, err := key.NewSecp256k1Provider([]byte("replay secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jws, err := provider.Authenticate(types.AuthParams{Aud: "gateway", Nonce: "client nonce"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := did.NewDidManager(nil, key.NewKeyResolver())
+	options := did.AuthVerifyOptions{Aud: "gateway", ReplayCache: replay.NewMemoryCache(nil)}
+	payload, err := verifier.VerifyAuthentication(jws, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Did != provider.Did() {
+		t.Fatalf("unexpected did %s", payload.Did)
+	}
+	if _, err = verifier.VerifyAuthentication(jws, options); err == nil {
+		t.Fatal("replayed authentication accepted")
+	}
+
+	// a response rejected for its scope keeps its nonce
+	scoped, err := provider.Authenticate(types.AuthParams{Aud: "gateway", Nonce: "scoped nonce", Paths: []string{"/models/*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options.Resource = "/files/1"
+	if _, err = verifier.VerifyAuthentication(scoped, options); err == nil {
+		t.Fatal("authentication out of scope accepted")
+	}
+	options.Resource = "/models/1"
+	if _, err = verifier.VerifyAuthentication(scoped, options); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.Issue(provider, jwt.Claims{}, jwt.IssueOptions{Lifetime: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	validate := jwt.ValidateOptions{ReplayCache: replay.NewMemoryCache(nil)}
+	if _, err = jwt.Validate(token, key.NewKeyResolver(), validate); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = jwt.Validate(token, key.NewKeyResolver(), validate); err == nil {
+		t.Fatal("replayed jwt accepted")
+	}
+}
+
+func TestAuthenticationClockSkew(t *testing.T) {
+	provider, err := key.NewSecp256k1Provider([]byte("clock secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedAt := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	provider.SetClock(fixedClock{signedAt})
+	jws, err := provider.Authenticate(types.AuthParams{Aud: "gateway", Nonce: "n", Lifetime: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := did.NewDidManager(nil, key.NewKeyResolver())
+	verifier.Clock = fixedClock{signedAt.Add(90 * time.Second)}
+	options := did.AuthVerifyOptions{Aud: "gateway", Nonce: "n"}
+	if _, err = verifier.VerifyAuthentication(jws, options); err == nil {
+		t.Fatal("expired authentication accepted")
+	}
+	verifier.ClockSkew = time.Minute
+	if _, err = verifier.VerifyAuthentication(jws, options); err != nil {
+		t.Fatal(err)
+	}
+}
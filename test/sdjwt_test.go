@@ -0,0 +1,100 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/sdjwt"
+)
+
+func TestSdJwt(t *testing.T) {
+	issuer, err := key.NewSecp256k1Provider([]byte("issuer secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	holder, err := key.NewSecp256k1Provider([]byte("holder secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued, err := sdjwt.Issue(issuer, map[string]any{
+		"sub":         holder.Did(),
+		"birthdate":   "1990-01-01",
+		"age_over_18": true,
+	}, sdjwt.IssueOptions{Holder: holder.Did(), Disclosable: []string{"birthdate", "age_over_18"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(strings.Split(issued, "~")[0], "1990") {
+		t.Fatal("disclosable claim leaked in the issuer jwt")
+	}
+
+	presentation, err := sdjwt.Present(holder, issued, sdjwt.PresentOptions{
+		Disclose: []string{"age_over_18"},
+		Nonce:    "n-0S6_WzA2Mj",
+		Aud:      "gateway.sao.network",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := sdjwt.VerifyOptions{RequireKeyBinding: true, Nonce: "n-0S6_WzA2Mj", Aud: "gateway.sao.network"}
+	result, err := sdjwt.Verify(presentation, key.NewKeyResolver(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Claims["age_over_18"] != true || result.Claims["birthdate"] != nil || result.Holder != holder.Did() {
+		t.Fatalf("unexpected claims %+v", result.Claims)
+	}
+
+	options.Nonce = "replayed"
+	if _, err = sdjwt.Verify(presentation, key.NewKeyResolver(), options); err == nil {
+		t.Fatal("key binding with a wrong nonce verified")
+	}
+
+	parsed, err := sdjwt.Parse(issued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range parsed.Disclosures {
+		if d.Name != "birthdate" {
+			continue
+		}
+		options.Nonce = "n-0S6_WzA2Mj"
+		forged := strings.Replace(presentation, "~", "~"+d.Encoded+"~", 1)
+		if _, err = sdjwt.Verify(forged, key.NewKeyResolver(), options); err == nil {
+			t.Fatal("disclosure added after key binding verified")
+		}
+	}
+
+	issuedAt := fixedClock{time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)}
+	issued, err = sdjwt.Issue(issuer, map[string]any{"age_over_18": true}, sdjwt.IssueOptions{
+		Holder:      holder.Did(),
+		Disclosable: []string{"age_over_18"},
+		Clock:       issuedAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	presentation, err = sdjwt.Present(holder, issued, sdjwt.PresentOptions{
+		Disclose: []string{"age_over_18"},
+		Nonce:    "n-0S6_WzA2Mj",
+		Aud:      "gateway.sao.network",
+		Clock:    issuedAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options.Clock = fixedClock{issuedAt.now.Add(time.Minute)}
+	result, err = sdjwt.Verify(presentation, key.NewKeyResolver(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Claims["iat"] != float64(issuedAt.now.Unix()) {
+		t.Fatalf("iat not taken from the clock: %v", result.Claims["iat"])
+	}
+	options.Clock = nil
+	if _, err = sdjwt.Verify(presentation, key.NewKeyResolver(), options); err == nil {
+		t.Fatal("stale key binding verified")
+	}
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/jwt"
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/session"
+	"github.com/SaoNetwork/sao-did/types"
+)
+
+func TestSessionKey(t *testing.T) {
+	parent, err := key.NewSecp256k1Provider([]byte("long lived secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := session.Create(parent, session.Options{Paths: []string{"/models/notes"}, Lifetime: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Did() != parent.Did() || s.SessionDid() == parent.Did() {
+		t.Fatalf("unexpected session dids %s %s", s.Did(), s.SessionDid())
+	}
+
+	dm := did.NewDidManager(nil, key.NewKeyResolver())
+	dm.Id = parent.Did()
+	jws, err := s.CreateJWS([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kid, capability, err := dm.VerifySessionJWS(jws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(kid, parent.Did()+"#") || capability == nil || capability.Paths[0] != "/models/notes" {
+		t.Fatalf("kid %s is not a parent key of %+v", kid, capability)
+	}
+	if _, err = dm.VerifyJWS(jws); err == nil {
+		t.Fatal("session signature accepted without path scope")
+	}
+	token, err := jwt.Issue(s, jwt.Claims{Subject: parent.Did()}, jwt.IssueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = jwt.Validate(token, key.NewKeyResolver(), jwt.ValidateOptions{}); err == nil {
+		t.Fatal("jwt signed by a session key accepted")
+	}
+
+	auth, err := s.Authenticate(types.AuthParams{Aud: "gateway", Nonce: "n", Paths: []string{"/models/notes/1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := dm.VerifyAuthentication(auth, did.AuthVerifyOptions{Aud: "gateway", Nonce: "n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Did != parent.Did() {
+		t.Fatalf("unexpected did %s", payload.Did)
+	}
+	if _, err = dm.VerifyAuthentication(auth, did.AuthVerifyOptions{Aud: "gateway", Resource: "/models/todos"}); err == nil {
+		t.Fatal("resource outside the authenticated paths accepted")
+	}
+	auth, err = s.Authenticate(types.AuthParams{Aud: "gateway", Nonce: "n", Paths: []string{"/models/other"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dm.VerifyAuthentication(auth, did.AuthVerifyOptions{Aud: "gateway", Nonce: "n"}); err == nil {
+		t.Fatal("authentication outside the capability paths accepted")
+	}
+
+	dm.Clock = fixedClock{time.Now().Add(2 * time.Hour)}
+	if _, _, err = dm.VerifySessionJWS(jws); err == nil {
+		t.Fatal("expired capability accepted")
+	}
+
+	// a capability delegated by another DID is rejected
+	other, err := key.NewSecp256k1Provider([]byte("someone else"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := session.Create(other, session.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jws, err = foreign.CreateJWS([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm.Clock = nil
+	if _, _, err = dm.VerifySessionJWS(jws); err == nil {
+		t.Fatal("capability of another did accepted")
+	}
+
+	if _, err = session.Create(parent, session.Options{Paths: []string{""}}); err == nil {
+		t.Fatal("capability with an empty path created")
+	}
+}
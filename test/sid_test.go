@@ -0,0 +1,122 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/sid"
+	"github.com/SaoNetwork/sao-did/types"
+	"github.com/dvsekhvalnov/jose2go/base64url"
+)
+
+// sidVersions is an in-memory SID version store, it ignores versionTime
+// and leaves walking the history to the resolver.
+type sidVersions map[string]*sid.SidDocument
+
+func (v sidVersions) query(id string, selector sid.VersionSelector) (*sid.SidDocument, error) {
+	for _, doc := range v {
+		if doc.Sid != id {
+			continue
+		}
+		if selector.VersionId == doc.VersionId || selector.VersionId == "" && doc.NextVersionId == "" {
+			return doc, nil
+		}
+	}
+	return nil, nil
+}
+
+func sidKey(t *testing.T, provider *key.Secp256k1Provider) *sid.PubKey {
+	_, pubKey, err := key.DecodeDidKey(provider.Did())
+	if err != nil {
+		t.Fatal(err)
+	}
+	multikey, err := key.EncodeMultikey(key.KeyTypeSecp256k1, pubKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &sid.PubKey{Name: "signing", Value: multikey}
+}
+
+// signAs signs payload with provider under an arbitrary kid.
+func signAs(t *testing.T, provider *key.Secp256k1Provider, kid string, payload []byte) types.GeneralJWS {
+	header, err := json.Marshal(types.JWTHeader{Kid: kid, Alg: types.AlgES256K})
+	if err != nil {
+		t.Fatal(err)
+	}
+	protected := base64url.Encode(header)
+	encodedPayload := base64url.Encode(payload)
+	sig, err := provider.Sign([]byte(protected + "." + encodedPayload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types.GeneralJWS{
+		Payload:    encodedPayload,
+		Signatures: []types.JwsSignature{{Protected: protected, Signature: base64url.Encode(sig)}},
+	}
+}
+
+func TestSidVersionHistory(t *testing.T) {
+	first, _ := key.NewSecp256k1Provider([]byte("first sid key"))
+	second, _ := key.NewSecp256k1Provider([]byte("second sid key"))
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	rotated := created.Add(24 * time.Hour)
+	versions := sidVersions{
+		"v1": {Sid: "abc", VersionId: "v1", Keys: []*sid.PubKey{sidKey(t, first)}, Timestamp: created.Unix(), NextVersionId: "v2"},
+		"v2": {Sid: "abc", VersionId: "v2", Keys: []*sid.PubKey{sidKey(t, second)}, Timestamp: rotated.Unix(), PrevVersionId: "v1"},
+	}
+	resolver, err := sid.NewSidResolver(versions.query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := resolver.Resolve("did:sid:abc", types.DidResolutionOptions{})
+	metadata := result.DidDocumentMetadata
+	if metadata.VersionId != "v2" || metadata.Created != created.Format(time.RFC3339) ||
+		metadata.Updated != rotated.Format(time.RFC3339) || metadata.NextUpdate != "" {
+		t.Fatalf("unexpected latest metadata %+v", metadata)
+	}
+	result = resolver.Resolve("did:sid:abc?versionId=v1", types.DidResolutionOptions{})
+	metadata = result.DidDocumentMetadata
+	if metadata.NextVersionId != "v2" || metadata.NextUpdate != rotated.Format(time.RFC3339) {
+		t.Fatalf("unexpected v1 metadata %+v", metadata)
+	}
+	result = resolver.Resolve("did:sid:abc?versionTime="+created.Add(time.Hour).Format(time.RFC3339), types.DidResolutionOptions{})
+	if result.DidDocumentMetadata.VersionId != "v1" {
+		t.Fatalf("versionTime resolved %+v", result.DidDocumentMetadata)
+	}
+	result = resolver.Resolve("did:sid:abc?versionTime=2022-01-01T00:00:00Z", types.DidResolutionOptions{})
+	if result.DidResolutionMetadata.Error != types.NotFound {
+		t.Fatalf("resolved before creation: %+v", result)
+	}
+
+	result = resolver.Resolve("did:sid:other", types.DidResolutionOptions{})
+	if result.DidResolutionMetadata.Error != types.NotFound {
+		t.Fatalf("resolved an unknown sid: %+v", result)
+	}
+	// a store answering with the version of another SID is rejected
+	confused, err := sid.NewSidResolver(func(string, sid.VersionSelector) (*sid.SidDocument, error) {
+		return versions["v2"], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result = confused.Resolve("did:sid:other", types.DidResolutionOptions{})
+	if result.DidResolutionMetadata.Error != types.InvalidDid {
+		t.Fatalf("resolved the document of another sid: %+v", result)
+	}
+
+	// the first key is revoked by the rotation
+	dm := did.NewDidManager(nil, resolver)
+	jws := signAs(t, first, "did:sid:abc?versionId=v1#signing", []byte("hello"))
+	dm.Clock = fixedClock{created.Add(time.Hour)}
+	if _, err = dm.VerifyJWS(jws); err != nil {
+		t.Fatal(err)
+	}
+	dm.Clock = fixedClock{rotated.Add(time.Hour)}
+	if _, err = dm.VerifyJWS(jws); err == nil {
+		t.Fatal("revoked key accepted")
+	}
+}
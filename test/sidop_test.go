@@ -0,0 +1,296 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/session"
+	"github.com/SaoNetwork/sao-did/sid"
+	"github.com/SaoNetwork/sao-did/sidop"
+	"github.com/SaoNetwork/sao-did/types"
+)
+
+func TestSidGenesis(t *testing.T) {
+	first, _ := key.NewSecp256k1Provider([]byte("genesis key 1"))
+	second, _ := key.NewSecp256k1Provider([]byte("genesis key 2"))
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := sidop.GenesisOptions{KeyNames: []string{"signing", "backup"}, Clock: fixedClock{created}}
+	genesis, err := sidop.CreateGenesis([]types.DidProvider{first, second}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	duplicated := sidop.GenesisOptions{KeyNames: []string{"signing", "signing"}}
+	if _, err = sidop.CreateGenesis([]types.DidProvider{first, second}, duplicated); err == nil {
+		t.Fatal("genesis with duplicated key names created")
+	}
+	again, err := sidop.CreateGenesis([]types.DidProvider{first, second}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if genesis.Document.Sid != again.Document.Sid || genesis.Document.Sid != genesis.Cid.String() {
+		t.Fatalf("sid is not derived from the genesis cid: %s %s", genesis.Document.Sid, again.Document.Sid)
+	}
+	// pins the derivation, the base32 CIDv1 of the dag-cbor genesis block
+	if genesis.Document.Sid != "bafyreibceaysf4nq5dlsdgmsqz65ybtq5aaft6er7ne47dwqhvwp2chfka" {
+		t.Fatalf("sid derivation changed: %s", genesis.Document.Sid)
+	}
+
+	// the genesis survives the trip to the chain
+	data, err := json.Marshal(genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var submitted sidop.Genesis
+	if err = json.Unmarshal(data, &submitted); err != nil {
+		t.Fatal(err)
+	}
+	if err = sidop.VerifyGenesis(submitted); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := genesis
+	tampered.Document.Timestamp++
+	if err = sidop.VerifyGenesis(tampered); err == nil {
+		t.Fatal("tampered genesis accepted")
+	}
+	unsigned := genesis
+	unsigned.Jws.Signatures = unsigned.Jws.Signatures[:1]
+	if err = sidop.VerifyGenesis(unsigned); err == nil {
+		t.Fatal("genesis without every key signature accepted")
+	}
+	third, _ := key.NewSecp256k1Provider([]byte("genesis key 3"))
+	extra, err := third.CreateJWS(genesis.Cid.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	oversigned := genesis
+	oversigned.Jws.Signatures = append(append([]types.JwsSignature{}, genesis.Jws.Signatures...), extra.Signatures...)
+	if err = sidop.VerifyGenesis(oversigned); err == nil {
+		t.Fatal("genesis signed by a foreign key accepted")
+	}
+	resigned := genesis
+	resigned.Jws.Signatures = append(append([]types.JwsSignature{}, genesis.Jws.Signatures...), genesis.Jws.Signatures[0])
+	if err = sidop.VerifyGenesis(resigned); err == nil {
+		t.Fatal("genesis with a duplicated signature accepted")
+	}
+
+	doc := genesis.Document
+	versions := sidVersions{doc.VersionId: &doc}
+	resolver, err := sid.NewSidResolver(versions.query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm := did.NewDidManager(nil, resolver)
+	jws := signAs(t, second, "did:sid:"+doc.Sid+"#backup", []byte("hello"))
+	if _, err = dm.VerifyJWS(jws); err != nil {
+		t.Fatal(err)
+	}
+	// the signature must verify with the key named by kid, not any key
+	jws = signAs(t, second, "did:sid:"+doc.Sid+"#signing", []byte("hello"))
+	if _, err = dm.VerifyJWS(jws); err == nil {
+		t.Fatal("signature of backup accepted for signing")
+	}
+}
+
+func TestSidUpdateHistory(t *testing.T) {
+	first, _ := key.NewSecp256k1Provider([]byte("rotation key 1"))
+	second, _ := key.NewSecp256k1Provider([]byte("rotation key 2"))
+	third, _ := key.NewSecp256k1Provider([]byte("rotation key 3"))
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	genesis, err := sidop.CreateGenesis([]types.DidProvider{first}, sidop.GenesisOptions{
+		KeyNames: []string{"signing"},
+		Clock:    fixedClock{created},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := created.Add(24 * time.Hour)
+	rotation, err := sidop.CreateUpdate(genesis.Document, first, sidop.UpdateOptions{
+		Replace: []*sid.PubKey{{Name: "signing", Value: sidKey(t, second).Value}},
+		Clock:   fixedClock{rotated},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addition, err := sidop.CreateUpdate(rotation.Document, second, sidop.UpdateOptions{
+		Add:   []*sid.PubKey{{Name: "backup", Value: sidKey(t, third).Value}},
+		Clock: fixedClock{rotated.Add(time.Hour)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions, err := sidop.VerifyHistory(genesis, []sidop.Update{rotation, addition})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 || len(versions[2].Keys) != 2 || versions[0].NextVersionId != rotation.Document.VersionId {
+		t.Fatalf("unexpected versions %+v", versions)
+	}
+
+	// the removed key can no longer update the SID
+	if _, err = sidop.CreateUpdate(rotation.Document, first, sidop.UpdateOptions{}); err == nil {
+		t.Fatal("update by a removed key created")
+	}
+	for _, replace := range badSidKeys(t) {
+		if _, err = sidop.CreateUpdate(rotation.Document, second, sidop.UpdateOptions{Replace: replace}); err == nil {
+			t.Fatalf("update replacing keys with %+v created", replace)
+		}
+	}
+	forged, err := sidop.CreateUpdate(genesis.Document, first, sidop.UpdateOptions{Clock: fixedClock{rotated}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.Document.PrevVersionId = rotation.Document.VersionId
+	if _, err = sidop.VerifyHistory(genesis, []sidop.Update{rotation, forged}); err == nil {
+		t.Fatal("update signed by a removed key accepted")
+	}
+
+	store := sidVersions{}
+	for i := range versions {
+		store[versions[i].VersionId] = &versions[i]
+	}
+	resolver, err := sid.NewSidResolver(store.query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm := did.NewDidManager(nil, resolver)
+	dm.Clock = fixedClock{rotated.Add(2 * time.Hour)}
+	id := "did:sid:" + genesis.Document.Sid
+	if _, err = dm.VerifyJWS(signAs(t, third, id+"#backup", []byte("hello"))); err != nil {
+		t.Fatal(err)
+	}
+	old := signAs(t, first, id+"?versionId="+genesis.Document.VersionId+"#signing", []byte("hello"))
+	if _, err = dm.VerifyJWS(old); err == nil {
+		t.Fatal("rotated key accepted")
+	}
+}
+
+// badSidKeys returns key sets an update must not accept.
+func badSidKeys(t *testing.T) [][]*sid.PubKey {
+	secret := make32(9)
+	valid, err := key.EncodeMultikey(key.KeyTypeSecp256k1, mustPublicKey(t, key.KeyTypeSecp256k1, secret), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := key.EncodeMultikey(key.KeyTypeSecp256k1, secret, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519, err := key.EncodeMultikey(key.KeyTypeEd25519, mustPublicKey(t, key.KeyTypeEd25519, secret), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return [][]*sid.PubKey{
+		{nil},
+		{{Name: "", Value: valid}},
+		{{Name: "signing", Value: ""}},
+		{{Name: "signing", Value: valid}, {Name: "signing", Value: valid}},
+		{{Name: "signing", Value: private}},
+		{{Name: "signing", Value: ed25519}},
+		{},
+	}
+}
+
+func mustPublicKey(t *testing.T, keyType string, secret []byte) []byte {
+	pubKey, err := key.PublicKey(keyType, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pubKey
+}
+
+func TestSidDeactivation(t *testing.T) {
+	owner, _ := key.NewSecp256k1Provider([]byte("deactivation key"))
+	other, _ := key.NewSecp256k1Provider([]byte("unrelated key"))
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	genesis, err := sidop.CreateGenesis([]types.DidProvider{owner}, sidop.GenesisOptions{
+		KeyNames: []string{"signing"},
+		Clock:    fixedClock{created},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deactivated := created.Add(24 * time.Hour)
+	if _, err = sidop.CreateDeactivation(genesis.Document, other, sidop.DeactivationOptions{Clock: fixedClock{deactivated}}); err == nil {
+		t.Fatal("deactivation by an unrelated key created")
+	}
+	deactivation, err := sidop.CreateDeactivation(genesis.Document, owner, sidop.DeactivationOptions{Clock: fixedClock{deactivated}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions, err := sidop.VerifyHistory(genesis, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := versions
+	versions, err = sidop.Deactivate(history, deactivation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history[0].Deactivated || !versions[0].Deactivated {
+		t.Fatal("deactivation changed the verified history")
+	}
+	if _, err = sidop.CreateUpdate(versions[0], owner, sidop.UpdateOptions{}); err == nil {
+		t.Fatal("update of a deactivated sid created")
+	}
+
+	resolver, err := sid.NewSidResolver(sidVersions{versions[0].VersionId: &versions[0]}.query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := "did:sid:" + genesis.Document.Sid
+	result := resolver.Resolve(id, types.DidResolutionOptions{})
+	if !result.DidDocumentMetadata.Deactivated || result.DidDocumentMetadata.DeactivationTime != deactivated.Format(time.RFC3339) {
+		t.Fatalf("unexpected metadata %+v", result.DidDocumentMetadata)
+	}
+
+	jws := signAs(t, owner, id+"#signing", []byte("hello"))
+	dm := did.NewDidManager(nil, resolver)
+	dm.Clock = fixedClock{deactivated.Add(-time.Hour)}
+	if _, err = dm.VerifyJWS(jws); err != nil {
+		t.Fatal(err)
+	}
+	dm.Clock = fixedClock{deactivated.Add(time.Hour)}
+	if _, err = dm.VerifyJWS(jws); err == nil {
+		t.Fatal("signature accepted after deactivation")
+	}
+}
+
+func TestSidSessionSignatures(t *testing.T) {
+	owner, _ := key.NewSecp256k1Provider([]byte("session owner key"))
+	attacker, _ := key.NewSecp256k1Provider([]byte("session attacker key"))
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	genesis, err := sidop.CreateGenesis([]types.DidProvider{owner}, sidop.GenesisOptions{
+		KeyNames: []string{"signing"},
+		Clock:    fixedClock{created},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a session key scoped to some paths must not manage the SID
+	s, err := session.Create(owner, session.Options{Paths: []string{"/notes/?"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	takeover, err := sidop.CreateUpdate(genesis.Document, s, sidop.UpdateOptions{
+		Replace: []*sid.PubKey{{Name: "signing", Value: sidKey(t, attacker).Value}},
+		Clock:   fixedClock{created.Add(time.Hour)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = sidop.VerifyHistory(genesis, []sidop.Update{takeover}); err == nil {
+		t.Fatal("update signed by a session key accepted")
+	}
+	deactivation, err := sidop.CreateDeactivation(genesis.Document, s, sidop.DeactivationOptions{Clock: fixedClock{created.Add(time.Hour)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sidop.VerifyDeactivation(genesis.Document, deactivation); err == nil {
+		t.Fatal("deactivation signed by a session key accepted")
+	}
+}
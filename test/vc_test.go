@@ -0,0 +1,287 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SaoNetwork/sao-did/jwt"
+	"github.com/SaoNetwork/sao-did/key"
+	"github.com/SaoNetwork/sao-did/vc"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+func TestVerifyCredential(t *testing.T) {
+	issuer, err := key.NewSecp256k1Provider([]byte("issuer secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := key.NewSecp256k1Provider([]byte("subject secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := vc.IssueCredential(issuer, vc.Credential{
+		Id:                "urn:uuid:1d2f3c4b",
+		IssuanceDate:      "2023-01-01T00:00:00Z",
+		ExpirationDate:    "2024-01-01T00:00:00Z",
+		CredentialSubject: map[string]any{"id": subject.Did(), "name": "alice"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := fixedClock{time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)}
+	result, err := vc.VerifyCredential(token, key.NewKeyResolver(), vc.VerifyOptions{Clock: valid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Verified || result.Format != vc.FormatJWT || len(result.Checks) != 6 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if string(result.Credential.Issuer) != issuer.Did() ||
+		result.Credential.SubjectId() != subject.Did() ||
+		result.Credential.Id != "urn:uuid:1d2f3c4b" {
+		t.Fatalf("unexpected credential %+v", result.Credential)
+	}
+
+	expired := fixedClock{time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	result, err = vc.VerifyCredential(token, key.NewKeyResolver(), vc.VerifyOptions{Clock: expired})
+	if err == nil || result.Verified || result.Failed().Name != vc.CheckExpirationDate {
+		t.Fatalf("expired credential verified: %+v", result)
+	}
+
+	parts := strings.Split(token, ".")
+	forged, err := vc.IssueCredential(subject, vc.Credential{
+		IssuanceDate:      "2023-01-01T00:00:00Z",
+		CredentialSubject: map[string]any{"id": subject.Did(), "name": "mallory"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2]
+	result, err = vc.VerifyCredential(tampered, key.NewKeyResolver(), vc.VerifyOptions{Clock: valid})
+	if err == nil || result.Failed().Name != vc.CheckSignature {
+		t.Fatalf("tampered credential verified: %+v", result)
+	}
+
+	_, err = vc.VerifyCredential(`{"issuer":"did:key:z","proof":{}}`, key.NewKeyResolver(), vc.VerifyOptions{Clock: valid})
+	if err == nil {
+		t.Fatal("credential with an empty proof verified")
+	}
+
+	pinned, err := vc.IssueCredential(issuer, vc.Credential{
+		CredentialSubject: map[string]any{"id": subject.Did()},
+	}, vc.IssueOptions{Clock: valid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = vc.VerifyCredential(pinned, key.NewKeyResolver(), vc.VerifyOptions{Clock: valid})
+	if err != nil || result.Credential.IssuanceDate != "2023-06-01T00:00:00Z" {
+		t.Fatalf("issuanceDate not taken from the clock: %+v", result.Credential)
+	}
+}
+
+func TestVerifyPresentation(t *testing.T) {
+	issuer, err := key.NewSecp256k1Provider([]byte("issuer secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	holder, err := key.NewSecp256k1Provider([]byte("holder secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	credential, err := vc.IssueCredential(issuer, vc.Credential{
+		IssuanceDate:      "2023-01-01T00:00:00Z",
+		CredentialSubject: map[string]any{"id": holder.Did(), "ageOver": 18},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	presentation, err := vc.CreatePresentation(holder, []string{credential}, vc.PresentationOptions{
+		Challenge: "3uyB6kzE0h2q1Xv8",
+		Domain:    "gateway.sao.network",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := vc.PresentationVerifyOptions{
+		VerifyOptions: vc.VerifyOptions{Clock: fixedClock{time.Now()}},
+		Challenge:     "3uyB6kzE0h2q1Xv8",
+		Domain:        "gateway.sao.network",
+	}
+	result, err := vc.VerifyPresentation(presentation, key.NewKeyResolver(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Verified || result.Holder != holder.Did() || len(result.Credentials) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	options.Challenge = "another challenge"
+	result, err = vc.VerifyPresentation(presentation, key.NewKeyResolver(), options)
+	if err == nil || result.Failed().Name != vc.CheckChallenge {
+		t.Fatalf("replayed presentation verified: %+v", result)
+	}
+
+	thief, err := key.NewSecp256k1Provider([]byte("thief secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stolen, err := vc.CreatePresentation(thief, []string{credential}, vc.PresentationOptions{Challenge: "3uyB6kzE0h2q1Xv8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options.Challenge = "3uyB6kzE0h2q1Xv8"
+	options.Domain = ""
+	result, err = vc.VerifyPresentation(stolen, key.NewKeyResolver(), options)
+	if err == nil || result.Failed().Name != vc.CheckHolderBinding {
+		t.Fatalf("presentation of a stolen credential verified: %+v", result)
+	}
+
+	presented := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	pinned, err := vc.CreatePresentation(holder, []string{credential}, vc.PresentationOptions{
+		Challenge: "3uyB6kzE0h2q1Xv8",
+		Clock:     fixedClock{presented},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options.Clock = fixedClock{presented.Add(time.Minute)}
+	if _, err = vc.VerifyPresentation(pinned, key.NewKeyResolver(), options); err != nil {
+		t.Fatal(err)
+	}
+	options.Clock = fixedClock{presented.Add(time.Hour)}
+	result, err = vc.VerifyPresentation(pinned, key.NewKeyResolver(), options)
+	if err == nil || result.Failed().Name != vc.CheckExpirationDate {
+		t.Fatalf("expired presentation verified: %+v", result)
+	}
+
+	// a presentation without exp never expires, it is rejected
+	unbounded, err := jwt.Sign(holder, map[string]any{
+		"iss":   holder.Did(),
+		"nonce": "3uyB6kzE0h2q1Xv8",
+		"vp": map[string]any{
+			"@context":             []any{vc.CredentialsContext},
+			"type":                 []any{vc.VerifiablePresentationType},
+			"verifiableCredential": []any{credential},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options.Clock = fixedClock{time.Now()}
+	result, err = vc.VerifyPresentation(unbounded, key.NewKeyResolver(), options)
+	if err == nil || result.Failed().Name != vc.CheckExpirationDate {
+		t.Fatalf("presentation without exp verified: %+v", result)
+	}
+}
+
+func TestCredentialStatus(t *testing.T) {
+	issuer, err := key.NewSecp256k1Provider([]byte("issuer secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const listId = "https://sao.network/status/1"
+	for _, listType := range []string{vc.StatusList2021, vc.BitstringStatusList} {
+		list, err := vc.NewStatusList(vc.MinStatusListLength)
+		if err != nil {
+			t.Fatal(err)
+		}
+		listCredential, err := vc.IssueStatusListCredential(issuer, listId, vc.StatusPurposeRevocation, listType, list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		credential, err := vc.IssueCredential(issuer, vc.Credential{
+			CredentialSubject: map[string]any{"id": "did:sid:alice"},
+			CredentialStatus:  vc.NewStatusEntry(listId, 42, vc.StatusPurposeRevocation, listType),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fetcher := vc.StaticStatusListFetcher{listId: listCredential}
+		result, err := vc.VerifyCredential(credential, key.NewKeyResolver(), vc.VerifyOptions{StatusListFetcher: fetcher})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Checks[len(result.Checks)-1].Name != vc.CheckStatus {
+			t.Fatalf("status not checked: %+v", result.Checks)
+		}
+
+		if err = list.Set(42, true); err != nil {
+			t.Fatal(err)
+		}
+		fetcher[listId], err = vc.IssueStatusListCredential(issuer, listId, vc.StatusPurposeRevocation, listType, list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err = vc.VerifyCredential(credential, key.NewKeyResolver(), vc.VerifyOptions{StatusListFetcher: fetcher})
+		if err == nil || result.Failed().Name != vc.CheckStatus {
+			t.Fatalf("revoked credential verified: %+v", result)
+		}
+
+		// another list of the issuer, or a list of another purpose, does
+		// not tell the status of the credential
+		clear, err := vc.NewStatusList(vc.MinStatusListLength)
+		if err != nil {
+			t.Fatal(err)
+		}
+		substitutes := []struct{ id, purpose string }{
+			{"https://sao.network/status/2", vc.StatusPurposeRevocation},
+			{listId, vc.StatusPurposeSuspension},
+		}
+		for _, substitute := range substitutes {
+			other, err := vc.IssueStatusListCredential(issuer, substitute.id, substitute.purpose, listType, clear)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err = vc.VerifyCredential(credential, key.NewKeyResolver(), vc.VerifyOptions{
+				StatusListFetcher: vc.StaticStatusListFetcher{listId: other},
+			})
+			if err == nil || result.Failed().Name != vc.CheckStatus {
+				t.Fatalf("status list %s for %s accepted: %+v", substitute.id, substitute.purpose, result)
+			}
+		}
+
+		encoded, err := list.Encode(listType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := vc.DecodeStatusList(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		revoked, _ := decoded.Get(42)
+		active, _ := decoded.Get(43)
+		if decoded.Len() != vc.MinStatusListLength || !revoked || active {
+			t.Fatal("status list round trip failed")
+		}
+
+		// a credential with a bad signature does not reach the status list
+		parts := strings.Split(credential, ".")
+		forged := parts[0] + "." + parts[1] + "." + strings.Split(listCredential, ".")[2]
+		counter := &countingFetcher{fetcher: fetcher}
+		result, err = vc.VerifyCredential(forged, key.NewKeyResolver(), vc.VerifyOptions{StatusListFetcher: counter})
+		if err == nil || result.Failed().Name != vc.CheckSignature || counter.fetched != 0 {
+			t.Fatalf("status list fetched for a forged credential: %+v", result)
+		}
+	}
+}
+
+type countingFetcher struct {
+	fetcher vc.StatusListFetcher
+	fetched int
+}
+
+func (f *countingFetcher) FetchStatusList(url string) (string, error) {
+	f.fetched++
+	return f.fetcher.FetchStatusList(url)
+}
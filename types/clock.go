@@ -0,0 +1,16 @@
+package types
+
+import "time"
+
+// Clock reports the current time. Validity checks take a Clock so that
+// callers can pin time in tests or correct for a drifting local clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock backed by time.Now.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,158 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/SaoNetwork/sao-did/util"
+	"github.com/dvsekhvalnov/jose2go/base64url"
+	"golang.org/x/xerrors"
+)
+
+// flattenedJWS is the flattened JWS JSON serialization,
+// https://www.rfc-editor.org/rfc/rfc7515#section-7.2.2
+type flattenedJWS struct {
+	Payload   *string        `json:"payload,omitempty"`
+	Protected string         `json:"protected"`
+	Header    map[string]any `json:"header,omitempty"`
+	Signature string         `json:"signature"`
+}
+
+// GetProtectedHeader decodes the protected header of the signature.
+func (g JwsSignature) GetProtectedHeader() (JWTHeader, error) {
+	var header JWTHeader
+	err := util.Base64urlToJSON(g.Protected, &header)
+	if err != nil {
+		return JWTHeader{}, xerrors.New("parse JWTHeader failed: " + err.Error())
+	}
+	return header, nil
+}
+
+// IsDetached reports whether the payload is left out of the JWS. An empty
+// payload is always taken as detached, as in the compact serialization, so
+// JWS over an empty payload are neither created nor verified.
+func (g GeneralJWS) IsDetached() bool {
+	return g.Payload == ""
+}
+
+// Compact returns the header.payload.signature serialization. It needs a
+// single signature without unprotected header.
+func (g GeneralJWS) Compact() (string, error) {
+	if len(g.Signatures) != 1 {
+		return "", xerrors.New("compact serialization needs exactly one signature")
+	}
+	sig := g.Signatures[0]
+	if len(sig.Header) != 0 {
+		return "", xerrors.New("compact serialization has no unprotected header")
+	}
+	if strings.Contains(g.Payload, ".") {
+		return "", xerrors.New("unencoded payload with a period cannot be compact serialized, detach it")
+	}
+	return sig.Protected + "." + g.Payload + "." + sig.Signature, nil
+}
+
+// Flattened returns the flattened JSON serialization of the single signature.
+func (g GeneralJWS) Flattened() ([]byte, error) {
+	if len(g.Signatures) != 1 {
+		return nil, xerrors.New("flattened serialization needs exactly one signature")
+	}
+	flattened := flattenedJWS{
+		Protected: g.Signatures[0].Protected,
+		Header:    g.Signatures[0].Header,
+		Signature: g.Signatures[0].Signature,
+	}
+	if !g.IsDetached() {
+		flattened.Payload = &g.Payload
+	}
+	return json.Marshal(flattened)
+}
+
+// Detach returns the JWS without payload.
+func (g GeneralJWS) Detach() GeneralJWS {
+	return GeneralJWS{Signatures: g.Signatures}
+}
+
+// Attach returns the detached JWS with the payload, base64url encoded
+// unless the JWS is unencoded.
+func (g GeneralJWS) Attach(payload []byte) (GeneralJWS, error) {
+	if len(g.Signatures) == 0 {
+		return GeneralJWS{}, xerrors.New("jws has no signature")
+	}
+	header, err := g.Signatures[0].GetProtectedHeader()
+	if err != nil {
+		return GeneralJWS{}, err
+	}
+	attached := GeneralJWS{Signatures: g.Signatures}
+	if header.IsUnencoded() {
+		attached.Payload = string(payload)
+	} else {
+		attached.Payload = base64url.Encode(payload)
+	}
+	return attached, nil
+}
+
+// DecodePayload returns the payload bytes, decoding it unless the JWS is
+// unencoded.
+func (g GeneralJWS) DecodePayload() ([]byte, error) {
+	if len(g.Signatures) == 0 {
+		return nil, xerrors.New("jws has no signature")
+	}
+	header, err := g.Signatures[0].GetProtectedHeader()
+	if err != nil {
+		return nil, err
+	}
+	if header.IsUnencoded() {
+		return []byte(g.Payload), nil
+	}
+	return base64url.Decode(g.Payload)
+}
+
+// ParseCompact parses the compact serialization, a detached JWS has an
+// empty payload part.
+func ParseCompact(jws string) (GeneralJWS, error) {
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 {
+		return GeneralJWS{}, xerrors.New("compact jws should have 3 parts")
+	}
+	if parts[0] == "" || parts[2] == "" {
+		return GeneralJWS{}, xerrors.New("compact jws has an empty header or signature")
+	}
+	return GeneralJWS{
+		Payload:    parts[1],
+		Signatures: []JwsSignature{{Protected: parts[0], Signature: parts[2]}},
+	}, nil
+}
+
+// ParseJSON parses the general or the flattened JSON serialization.
+func ParseJSON(data []byte) (GeneralJWS, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return GeneralJWS{}, xerrors.Errorf("parse jws failed: %v", err)
+	}
+	if _, ok := fields["signatures"]; ok {
+		var jws GeneralJWS
+		if err := json.Unmarshal(data, &jws); err != nil {
+			return GeneralJWS{}, xerrors.Errorf("parse general jws failed: %v", err)
+		}
+		if len(jws.Signatures) == 0 {
+			return GeneralJWS{}, xerrors.New("jws has no signature")
+		}
+		return jws, nil
+	}
+	var flattened flattenedJWS
+	if err := json.Unmarshal(data, &flattened); err != nil {
+		return GeneralJWS{}, xerrors.Errorf("parse flattened jws failed: %v", err)
+	}
+	if flattened.Signature == "" {
+		return GeneralJWS{}, xerrors.New("jws has no signature")
+	}
+	jws := GeneralJWS{Signatures: []JwsSignature{{
+		Protected: flattened.Protected,
+		Header:    flattened.Header,
+		Signature: flattened.Signature,
+	}}}
+	if flattened.Payload != nil {
+		jws.Payload = *flattened.Payload
+	}
+	return jws, nil
+}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"time"
+
 	"github.com/SaoNetwork/sao-did/util"
 	"github.com/ipfs/go-cid"
 	"golang.org/x/xerrors"
@@ -10,11 +12,16 @@ type AuthParams struct {
 	Paths []string
 	Nonce string
 	Aud   string
+	// Lifetime of the authentication response, DefaultAuthLifetime when zero.
+	Lifetime time.Duration
 }
 
+// DefaultAuthLifetime is the default validity of an authentication response.
+const DefaultAuthLifetime = 600 * time.Second
+
 type GeneralJWS struct {
-	Payload    string
-	Signatures []JwsSignature
+	Payload    string         `json:"payload"`
+	Signatures []JwsSignature `json:"signatures"`
 }
 
 func (g GeneralJWS) ToDagJWS() DagJWS {
@@ -26,8 +33,9 @@ func (g GeneralJWS) ToDagJWS() DagJWS {
 }
 
 type JwsSignature struct {
-	Protected string
-	Signature string
+	Protected string         `json:"protected"`
+	Header    map[string]any `json:"header,omitempty"`
+	Signature string         `json:"signature"`
 }
 
 func (g JwsSignature) GetKid() (string, error) {
@@ -56,14 +64,67 @@ type DagJWSResult struct {
 }
 
 type DagJWS struct {
-	Payload    string
-	Signatures []JwsSignature
-	Link       *cid.Cid
+	Payload    string         `json:"payload"`
+	Signatures []JwsSignature `json:"signatures"`
+	Link       *cid.Cid       `json:"link,omitempty"`
+}
+
+// CreateDagJWSOptions configures the payload CID, which is always a CIDv1:
+// CIDv0 implies dag-pb, which is not a supported linked block codec.
+type CreateDagJWSOptions struct {
+	// HashFunction is the multihash code of the payload CID, SHA2-256 by
+	// default. Any registered multihash works, e.g. BLAKE3 or SHA3-256.
+	HashFunction uint64
+	// Codec of the linked block, dag-cbor by default or dag-json.
+	Codec uint64
+	// EmbedLinkedBlock adds the base64url linked block to the protected
+	// header as linkedBlock.
+	EmbedLinkedBlock bool
+	// ProtectedHeader holds additional protected header parameters.
+	ProtectedHeader map[string]any
+}
+
+// DagJWE is a JWE in the general JSON serialization, the IPLD form of an
+// encrypted block.
+type DagJWE struct {
+	Protected   string         `json:"protected"`
+	Unprotected map[string]any `json:"unprotected,omitempty"`
+	Iv          string         `json:"iv"`
+	Aad         string         `json:"aad,omitempty"`
+	Ciphertext  string         `json:"ciphertext"`
+	Tag         string         `json:"tag"`
+	Recipients  []JweRecipient `json:"recipients,omitempty"`
+}
+
+type JweRecipient struct {
+	Header       map[string]any `json:"header,omitempty"`
+	EncryptedKey string         `json:"encrypted_key,omitempty"`
 }
 
 type JWTHeader struct {
-	Kid string `json:"kid"`
-	Alg string `json:"alg"`
+	Kid  string   `json:"kid"`
+	Alg  string   `json:"alg"`
+	Typ  string   `json:"typ,omitempty"`
+	B64  *bool    `json:"b64,omitempty"`
+	Crit []string `json:"crit,omitempty"`
+}
+
+// IsUnencoded reports whether the payload is not base64url encoded, see
+// https://www.rfc-editor.org/rfc/rfc7797
+func (h JWTHeader) IsUnencoded() bool {
+	return h.B64 != nil && !*h.B64
+}
+
+type CreateJWSOptions struct {
+	// ProtectedHeader holds additional protected header parameters, kid and
+	// alg are always set by the provider.
+	ProtectedHeader map[string]any
+	// Detached leaves the payload out of the JWS, the verifier gets it
+	// by other means.
+	Detached bool
+	// Unencoded signs the payload as is instead of its base64url encoding,
+	// with the b64=false and crit header parameters.
+	Unencoded bool
 }
 
 type Payload struct {
@@ -72,9 +133,11 @@ type Payload struct {
 	Nonce string   `json:"nonce"`
 	Paths []string `json:"paths"`
 	Exp   int64    `json:"exp"`
+	Jti   string   `json:"jti,omitempty"`
 }
 
 type DidProvider interface {
+	Did() string
 	Authenticate(params AuthParams) (GeneralJWS, error)
-	CreateJWS(payload []byte) (GeneralJWS, error)
+	CreateJWS(payload []byte, options ...CreateJWSOptions) (GeneralJWS, error)
 }
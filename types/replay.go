@@ -0,0 +1,11 @@
+package types
+
+import "time"
+
+// ReplayCache remembers single-use values such as authentication nonces and
+// JWT ids until they expire.
+type ReplayCache interface {
+	// Use records key until expiry. It returns false, without recording,
+	// when key was already used and has not expired yet.
+	Use(key string, expiry time.Time) (bool, error)
+}
@@ -1,5 +1,7 @@
 package types
 
+import "strings"
+
 const (
 	InvalidDid                 = "invalidDid"
 	NotFound                   = "notFound"
@@ -11,6 +13,10 @@ var InvalidDidResult = DidResolutionResult{
 	DidResolutionMetadata: DidResolutionMetadata{Error: InvalidDid},
 }
 
+var NotFoundResult = DidResolutionResult{
+	DidResolutionMetadata: DidResolutionMetadata{Error: NotFound},
+}
+
 var RepresentationNotSupportResult = DidResolutionResult{
 	DidResolutionMetadata: DidResolutionMetadata{Error: RepresentationNotSupported},
 }
@@ -37,8 +43,40 @@ type DidDocument struct {
 	Controller         []string
 	VerificationMethod []VerificationMethod
 	//Service            []Service
-	Authentication []any
-	KeyAgreement   []VerificationMethod
+	Authentication  []any
+	AssertionMethod []any
+	KeyAgreement    []VerificationMethod
+}
+
+// HasAssertionMethod reports whether the verification method id is listed in
+// the assertionMethod relationship, either by reference or embedded.
+func (d DidDocument) HasAssertionMethod(id string) bool {
+	return d.hasReference(d.AssertionMethod, id)
+}
+
+// HasAuthentication reports whether the verification method id is listed in
+// the authentication relationship, either by reference or embedded.
+func (d DidDocument) HasAuthentication(id string) bool {
+	return d.hasReference(d.Authentication, id)
+}
+
+func (d DidDocument) hasReference(relationship []any, id string) bool {
+	for _, vm := range relationship {
+		var vmId string
+		switch v := vm.(type) {
+		case string:
+			vmId = v
+		case VerificationMethod:
+			vmId = v.Id
+		}
+		if strings.HasPrefix(vmId, "#") {
+			vmId = d.Id + vmId
+		}
+		if vmId == id {
+			return true
+		}
+	}
+	return false
 }
 
 type VerificationMethod struct {
@@ -57,6 +95,8 @@ type DidDocumentMetadata struct {
 	VersionId     string
 	NextVersionId string
 	EquivalentId  string
+	// DeactivationTime is the RFC3339 time the DID was deactivated.
+	DeactivationTime string
 }
 
 type DidResolutionOptions struct {
@@ -0,0 +1,16 @@
+package types
+
+// CapabilityHeader is the protected header parameter of a JWS signed by a
+// session key that holds the compact JWS of its Capability.
+const CapabilityHeader = "cap"
+
+// Capability is signed by Iss to let the session key Aud, a did:key, sign
+// on its behalf for Paths until Exp.
+type Capability struct {
+	Iss   string   `json:"iss"`
+	Aud   string   `json:"aud"`
+	Paths []string `json:"paths,omitempty"`
+	Nonce string   `json:"nonce,omitempty"`
+	Iat   int64    `json:"iat"`
+	Exp   int64    `json:"exp"`
+}
@@ -0,0 +1,19 @@
+package types
+
+// JWS algorithms of the supported key types.
+const (
+	AlgES256K = "ES256K"
+	AlgEdDSA  = "EdDSA"
+)
+
+// Signer holds a private key, in memory or in another process, so that
+// providers never handle raw secrets.
+type Signer interface {
+	// PublicKey returns the raw public key, compressed for secp256k1.
+	PublicKey() []byte
+	// Algorithm returns the JWS alg of the key.
+	Algorithm() string
+	// Sign signs data as Algorithm requires: ES256K hashes data with
+	// SHA-256 first, EdDSA signs it as is.
+	Sign(data []byte) ([]byte, error)
+}
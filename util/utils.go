@@ -1,6 +1,7 @@
 package util
 
 import (
+	"bytes"
 	"encoding/json"
 	"github.com/SaoNetwork/sao-did/parser"
 	"github.com/dvsekhvalnov/jose2go/base64url"
@@ -22,3 +23,21 @@ func KidToDid(kid string) (string, error) {
 	}
 	return strings.Join([]string{"did", headerDid.Method, headerDid.ID}, ":"), nil
 }
+
+// EncodeDagJSON encodes v as dag-json: compact JSON with map keys sorted.
+// CIDs are links through their JSON marshalling; byte slices are not
+// turned into dag-json bytes and must not be used.
+func EncodeDagJSON(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic any
+	if err = decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	// encoding/json sorts map keys bytewise, as dag-json requires
+	return json.Marshal(generic)
+}
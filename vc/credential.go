@@ -0,0 +1,116 @@
+// Package vc issues and verifies W3C Verifiable Credentials
+// (https://www.w3.org/TR/vc-data-model/) for SAO identities.
+package vc
+
+import (
+	"encoding/json"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	CredentialsContext       = "https://www.w3.org/2018/credentials/v1"
+	VerifiableCredentialType = "VerifiableCredential"
+
+	// FormatJWT and FormatLDP name the two supported credential securing
+	// mechanisms: vc-jwt and embedded (linked data) proofs.
+	FormatJWT = "jwt"
+	FormatLDP = "ldp"
+)
+
+// Credential is the typed view of a verifiable credential. Properties that
+// are not modelled here are kept by the raw JSON document and survive
+// proof verification.
+type Credential struct {
+	Context           []any             `json:"@context"`
+	Id                string            `json:"id,omitempty"`
+	Type              Types             `json:"type"`
+	Issuer            Issuer            `json:"issuer"`
+	IssuanceDate      string            `json:"issuanceDate,omitempty"`
+	ExpirationDate    string            `json:"expirationDate,omitempty"`
+	CredentialSubject map[string]any    `json:"credentialSubject"`
+	CredentialStatus  *CredentialStatus `json:"credentialStatus,omitempty"`
+	Proof             map[string]any    `json:"proof,omitempty"`
+}
+
+// CredentialStatus is a status list entry, it points at the bit of a status
+// list credential that tells whether the credential is revoked or suspended.
+type CredentialStatus struct {
+	Id                   string `json:"id"`
+	Type                 string `json:"type"`
+	StatusPurpose        string `json:"statusPurpose"`
+	StatusListIndex      string `json:"statusListIndex"`
+	StatusListCredential string `json:"statusListCredential"`
+}
+
+// SubjectId returns the id of the credential subject, if any.
+func (c Credential) SubjectId() string {
+	id, _ := c.CredentialSubject["id"].(string)
+	return id
+}
+
+// Issuer is the issuer DID. It unmarshals from both the string and the
+// object ({"id": ...}) forms.
+type Issuer string
+
+func (i *Issuer) UnmarshalJSON(data []byte) error {
+	var id string
+	if err := json.Unmarshal(data, &id); err == nil {
+		*i = Issuer(id)
+		return nil
+	}
+	var obj struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return xerrors.Errorf("invalid issuer: %v", err)
+	}
+	*i = Issuer(obj.Id)
+	return nil
+}
+
+// Types is a JSON-LD type value, which is either a single string or an array.
+type Types []string
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return xerrors.Errorf("invalid type: %v", err)
+	}
+	*t = multiple
+	return nil
+}
+
+func (t Types) Contains(typ string) bool {
+	for _, v := range t {
+		if v == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// toMap round trips v through JSON so it can be embedded in or compared
+// with other JSON documents.
+func toMap(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+func fromMap(m map[string]any, v any) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
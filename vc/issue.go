@@ -0,0 +1,96 @@
+package vc
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/SaoNetwork/sao-did/jwt"
+	"github.com/SaoNetwork/sao-did/proof"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"golang.org/x/xerrors"
+)
+
+type IssueOptions struct {
+	// Clock sets the default issuanceDate, defaults to the system clock.
+	Clock saotypes.Clock
+}
+
+// IssueCredential signs the credential with the provider and returns it as
+// a vc-jwt. Missing @context, type, issuer and issuanceDate are filled in.
+func IssueCredential(provider saotypes.DidProvider, credential Credential, options ...IssueOptions) (string, error) {
+	var clock saotypes.Clock
+	if len(options) > 0 {
+		clock = options[0].Clock
+	}
+	credential, err := prepareCredential(provider, credential, clock)
+	if err != nil {
+		return "", err
+	}
+	if credential.Proof != nil {
+		return "", xerrors.New("a vc-jwt must not carry an embedded proof")
+	}
+
+	nbf, err := unixTime(credential.IssuanceDate)
+	if err != nil {
+		return "", xerrors.Errorf("issuanceDate should be RFC3339 format: %v", err)
+	}
+	exp, err := unixTime(credential.ExpirationDate)
+	if err != nil {
+		return "", xerrors.Errorf("expirationDate should be RFC3339 format: %v", err)
+	}
+	vc, err := toMap(credential)
+	if err != nil {
+		return "", err
+	}
+	return jwt.Sign(provider, credentialClaims{
+		Iss: string(credential.Issuer),
+		Sub: credential.SubjectId(),
+		Jti: credential.Id,
+		Nbf: nbf,
+		Exp: exp,
+		Vc:  vc,
+	}, nil)
+}
+
+// IssueLdpCredential adds an embedded proof of the suite to the credential
+// and returns it as JSON.
+func IssueLdpCredential(signer proof.Signer, credential Credential, suite proof.Suite, options proof.Options) (string, error) {
+	credential, err := prepareCredential(signer, credential, options.Clock)
+	if err != nil {
+		return "", err
+	}
+	document, err := toMap(credential)
+	if err != nil {
+		return "", err
+	}
+	secured, err := proof.Sign(document, signer, suite, options)
+	if err != nil {
+		return "", err
+	}
+	bytes, err := json.Marshal(secured)
+	return string(bytes), err
+}
+
+func prepareCredential(provider saotypes.DidProvider, credential Credential, clock saotypes.Clock) (Credential, error) {
+	if provider == nil {
+		return credential, xerrors.New("provider is missing.")
+	}
+	if credential.Issuer == "" {
+		credential.Issuer = Issuer(provider.Did())
+	} else if string(credential.Issuer) != provider.Did() {
+		return credential, xerrors.Errorf("issuer %s does not match provider did %s", credential.Issuer, provider.Did())
+	}
+	if len(credential.Context) == 0 {
+		credential.Context = []any{CredentialsContext}
+	}
+	if !credential.Type.Contains(VerifiableCredentialType) {
+		credential.Type = append(Types{VerifiableCredentialType}, credential.Type...)
+	}
+	if credential.IssuanceDate == "" {
+		if clock == nil {
+			clock = saotypes.SystemClock{}
+		}
+		credential.IssuanceDate = clock.Now().UTC().Format(time.RFC3339)
+	}
+	return credential, nil
+}
@@ -0,0 +1,36 @@
+package vc
+
+import (
+	"strings"
+	"time"
+)
+
+// credentialClaims are the JWT claims of a vc-jwt, see
+// https://www.w3.org/TR/vc-data-model/#jwt-encoding
+type credentialClaims struct {
+	Iss string         `json:"iss"`
+	Sub string         `json:"sub,omitempty"`
+	Jti string         `json:"jti,omitempty"`
+	Nbf int64          `json:"nbf,omitempty"`
+	Exp int64          `json:"exp,omitempty"`
+	Vc  map[string]any `json:"vc"`
+}
+
+func isJWT(credential string) bool {
+	return !strings.HasPrefix(strings.TrimSpace(credential), "{") && strings.Count(credential, ".") == 2
+}
+
+func unixTime(rfc3339 string) (int64, error) {
+	if rfc3339 == "" {
+		return 0, nil
+	}
+	t, err := time.Parse(time.RFC3339, rfc3339)
+	if err != nil {
+		return 0, err
+	}
+	return t.Unix(), nil
+}
+
+func formatUnix(sec int64) string {
+	return time.Unix(sec, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,241 @@
+package vc
+
+import (
+	"encoding/json"
+	"time"
+
+	did "github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/jwt"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"github.com/SaoNetwork/sao-did/util"
+	"golang.org/x/xerrors"
+)
+
+const (
+	VerifiablePresentationType = "VerifiablePresentation"
+
+	defaultPresentationLifetime = 600 * time.Second
+)
+
+// Names of the checks recorded in a PresentationResult.
+const (
+	CheckHolder        = "holder"
+	CheckChallenge     = "challenge"
+	CheckDomain        = "domain"
+	CheckCredentials   = "credentials"
+	CheckHolderBinding = "holderBinding"
+)
+
+type Presentation struct {
+	Context              []any  `json:"@context"`
+	Id                   string `json:"id,omitempty"`
+	Type                 Types  `json:"type"`
+	Holder               string `json:"holder"`
+	VerifiableCredential []any  `json:"verifiableCredential,omitempty"`
+}
+
+// presentationClaims are the JWT claims of a vp-jwt. nonce and aud carry
+// the verifier's challenge and domain, as in DidManager.Authenticate.
+type presentationClaims struct {
+	Iss   string         `json:"iss"`
+	Aud   string         `json:"aud,omitempty"`
+	Nonce string         `json:"nonce,omitempty"`
+	Jti   string         `json:"jti,omitempty"`
+	Iat   int64          `json:"iat"`
+	Exp   int64          `json:"exp"`
+	Vp    map[string]any `json:"vp"`
+}
+
+type PresentationOptions struct {
+	// Challenge is the verifier supplied nonce the presentation is bound to.
+	Challenge string
+	// Domain is the verifier the presentation is intended for.
+	Domain string
+	// Lifetime of the presentation, defaults to 600 seconds.
+	Lifetime time.Duration
+	// Clock sets iat and exp, defaults to the system clock.
+	Clock saotypes.Clock
+}
+
+// CreatePresentation wraps the credentials, each either a vc-jwt or a JSON
+// credential with an embedded proof, into a vp-jwt signed by the holder.
+func CreatePresentation(holder saotypes.DidProvider, credentials []string, options PresentationOptions) (string, error) {
+	if holder == nil {
+		return "", xerrors.New("provider is missing.")
+	}
+	if options.Challenge == "" {
+		return "", xerrors.New("challenge is missing.")
+	}
+	presentation := Presentation{
+		Context: []any{CredentialsContext},
+		Type:    Types{VerifiablePresentationType},
+		Holder:  holder.Did(),
+	}
+	for _, credential := range credentials {
+		if isJWT(credential) {
+			presentation.VerifiableCredential = append(presentation.VerifiableCredential, credential)
+			continue
+		}
+		var document map[string]any
+		if err := json.Unmarshal([]byte(credential), &document); err != nil {
+			return "", xerrors.Errorf("invalid credential: %v", err)
+		}
+		presentation.VerifiableCredential = append(presentation.VerifiableCredential, document)
+	}
+	vp, err := toMap(presentation)
+	if err != nil {
+		return "", err
+	}
+
+	lifetime := options.Lifetime
+	if lifetime == 0 {
+		lifetime = defaultPresentationLifetime
+	}
+	clock := options.Clock
+	if clock == nil {
+		clock = saotypes.SystemClock{}
+	}
+	now := clock.Now()
+	return jwt.Sign(holder, presentationClaims{
+		Iss:   presentation.Holder,
+		Aud:   options.Domain,
+		Nonce: options.Challenge,
+		Iat:   now.Unix(),
+		Exp:   now.Add(lifetime).Unix(),
+		Vp:    vp,
+	}, nil)
+}
+
+type PresentationVerifyOptions struct {
+	VerifyOptions
+	// Challenge is the nonce issued to the holder. It must be fresh for
+	// every presentation request, otherwise presentations can be replayed.
+	Challenge string
+	// Domain is the expected audience, checked when set.
+	Domain string
+}
+
+type PresentationResult struct {
+	Verified     bool
+	Holder       string
+	Presentation Presentation
+	Credentials  []VerificationResult
+	Checks       Checks
+}
+
+// Failed returns the first failed check, if any.
+func (r PresentationResult) Failed() *Check {
+	return r.Checks.Failed()
+}
+
+// VerifyPresentation verifies a vp-jwt: the holder signature and
+// authentication key, the challenge and domain, every embedded credential
+// and that each credential subject is the holder.
+func VerifyPresentation(presentation string, resolver saotypes.DidResolver, options PresentationVerifyOptions) (PresentationResult, error) {
+	result := PresentationResult{}
+	if resolver == nil {
+		return result, xerrors.New("resolver is missing.")
+	}
+	if options.Challenge == "" {
+		return result, xerrors.New("challenge is missing.")
+	}
+	clock := options.Clock
+	if clock == nil {
+		clock = saotypes.SystemClock{}
+	}
+
+	var claims presentationClaims
+	jws, err := jwt.Decode(presentation, &claims)
+	if err == nil {
+		if claims.Vp == nil {
+			err = xerrors.New("jwt has no vp claim")
+		} else {
+			err = fromMap(claims.Vp, &result.Presentation)
+		}
+	}
+	if err == nil {
+		err = mergeClaim(claims.Vp, "holder", claims.Iss)
+		result.Holder = claims.Iss
+	}
+	if err = result.Checks.record(CheckFormat, err); err != nil {
+		return result, err
+	}
+
+	dm := did.NewDidManager(nil, resolver)
+	dm.Clock = options.Clock
+	kid, err := dm.VerifyJWS(jws)
+	result.Checks.record(CheckSignature, err)
+	if err == nil {
+		result.Checks.record(CheckHolder, checkHolder(kid, result.Holder, resolver))
+	}
+	result.Checks.record(CheckChallenge, checkEqual("nonce", claims.Nonce, options.Challenge))
+	if options.Domain != "" {
+		result.Checks.record(CheckDomain, checkEqual("aud", claims.Aud, options.Domain))
+	}
+	result.Checks.record(CheckExpirationDate, checkPresentationExpiry(claims.Exp, clock.Now()))
+
+	var credentialErr, bindingErr error
+	for _, vc := range result.Presentation.VerifiableCredential {
+		credential, ok := vc.(string)
+		if !ok {
+			bytes, err := json.Marshal(vc)
+			if err != nil {
+				return result, err
+			}
+			credential = string(bytes)
+		}
+		vcResult, err := VerifyCredential(credential, resolver, options.VerifyOptions)
+		result.Credentials = append(result.Credentials, vcResult)
+		if err != nil && credentialErr == nil {
+			credentialErr = err
+		}
+		if subject := vcResult.Credential.SubjectId(); subject != result.Holder && bindingErr == nil {
+			bindingErr = xerrors.Errorf("credential subject %s is not the holder %s", subject, result.Holder)
+		}
+	}
+	result.Checks.record(CheckCredentials, credentialErr)
+	result.Checks.record(CheckHolderBinding, bindingErr)
+
+	if err = result.Checks.err(); err != nil {
+		return result, err
+	}
+	result.Verified = true
+	return result, nil
+}
+
+func checkHolder(kid string, holder string, resolver saotypes.DidResolver) error {
+	signer, err := util.KidToDid(kid)
+	if err != nil {
+		return err
+	}
+	if signer != holder {
+		return xerrors.Errorf("presentation of %s signed by %s", holder, signer)
+	}
+	didResolutionResult := resolver.Resolve(kid, saotypes.DidResolutionOptions{})
+	if didResolutionResult.DidResolutionMetadata.Error != "" {
+		return xerrors.Errorf("resolve %s failed: %s", kid, didResolutionResult.DidResolutionMetadata.Error)
+	}
+	if !didResolutionResult.DidDocument.HasAuthentication(kid) {
+		return xerrors.Errorf("%s is not an authentication method of the holder", kid)
+	}
+	return nil
+}
+
+// checkPresentationExpiry requires exp, which CreatePresentation always
+// sets, so that presentations cannot be replayed forever.
+func checkPresentationExpiry(exp int64, now time.Time) error {
+	if exp == 0 {
+		return xerrors.New("presentation has no exp")
+	}
+	if now.Unix() >= exp {
+		return xerrors.New("presentation expired")
+	}
+	return nil
+}
+
+func checkEqual(claim string, actual string, expected string) error {
+	if actual != expected {
+		return xerrors.Errorf("wrong %s", claim)
+	}
+	return nil
+}
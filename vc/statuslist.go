@@ -0,0 +1,220 @@
+package vc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/SaoNetwork/sao-did/proof"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"golang.org/x/xerrors"
+)
+
+// Status list flavours, https://w3c.github.io/vc-status-list-2021/ and its
+// successor https://www.w3.org/TR/vc-bitstring-status-list/
+const (
+	StatusList2021      = "StatusList2021"
+	BitstringStatusList = "BitstringStatusList"
+
+	StatusPurposeRevocation = "revocation"
+	StatusPurposeSuspension = "suspension"
+
+	// MinStatusListLength is the minimum list size (16KB) recommended for
+	// herd privacy.
+	MinStatusListLength = 131072
+
+	maxStatusListBytes = 16 << 20
+)
+
+const CheckStatus = "status"
+
+// StatusListFetcher retrieves status list credentials, as vc-jwt or JSON,
+// by their URL.
+type StatusListFetcher interface {
+	FetchStatusList(url string) (string, error)
+}
+
+// StaticStatusListFetcher serves status list credentials from memory.
+type StaticStatusListFetcher map[string]string
+
+func (f StaticStatusListFetcher) FetchStatusList(url string) (string, error) {
+	credential, ok := f[url]
+	if !ok {
+		return "", xerrors.Errorf("status list %s not found", url)
+	}
+	return credential, nil
+}
+
+// StatusList is an uncompressed status bitstring. The first index is the
+// most significant bit of the first byte.
+type StatusList struct {
+	bits []byte
+}
+
+func NewStatusList(length int) (*StatusList, error) {
+	if length < MinStatusListLength || length%8 != 0 {
+		return nil, xerrors.Errorf("status list length should be a multiple of 8 and at least %d", MinStatusListLength)
+	}
+	return &StatusList{bits: make([]byte, length/8)}, nil
+}
+
+func (l *StatusList) Len() int {
+	return len(l.bits) * 8
+}
+
+func (l *StatusList) Set(index int, status bool) error {
+	if index < 0 || index >= l.Len() {
+		return xerrors.Errorf("status list index %d out of range", index)
+	}
+	mask := byte(0x80) >> (index % 8)
+	if status {
+		l.bits[index/8] |= mask
+	} else {
+		l.bits[index/8] &^= mask
+	}
+	return nil
+}
+
+func (l *StatusList) Get(index int) (bool, error) {
+	if index < 0 || index >= l.Len() {
+		return false, xerrors.Errorf("status list index %d out of range", index)
+	}
+	return l.bits[index/8]&(byte(0x80)>>(index%8)) != 0, nil
+}
+
+// Encode compresses the list with GZIP and encodes it as base64url, with the
+// multibase prefix for BitstringStatusList.
+func (l *StatusList) Encode(listType string) (string, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(l.bits); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(buf.Bytes())
+	if listType == BitstringStatusList {
+		encoded = "u" + encoded
+	}
+	return encoded, nil
+}
+
+// DecodeStatusList decodes an encodedList of either flavour.
+func DecodeStatusList(encoded string) (*StatusList, error) {
+	// a GZIP stream always starts with "H4sI" in base64, so the "u"
+	// multibase prefix is unambiguous
+	encoded = strings.TrimPrefix(encoded, "u")
+	compressed, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return nil, xerrors.Errorf("decode encodedList failed: %v", err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, xerrors.Errorf("decompress encodedList failed: %v", err)
+	}
+	bits, err := io.ReadAll(io.LimitReader(r, maxStatusListBytes+1))
+	if err != nil {
+		return nil, xerrors.Errorf("decompress encodedList failed: %v", err)
+	}
+	if len(bits) > maxStatusListBytes {
+		return nil, xerrors.New("status list is too large")
+	}
+	return &StatusList{bits: bits}, nil
+}
+
+// NewStatusEntry returns the credentialStatus of the credential with the
+// index in the status list credential.
+func NewStatusEntry(statusListCredential string, index int, purpose string, listType string) *CredentialStatus {
+	return &CredentialStatus{
+		Id:                   statusListCredential + "#" + strconv.Itoa(index),
+		Type:                 listType + "Entry",
+		StatusPurpose:        purpose,
+		StatusListIndex:      strconv.Itoa(index),
+		StatusListCredential: statusListCredential,
+	}
+}
+
+// IssueStatusListCredential issues the status list as a vc-jwt with the id
+// credentials refer to in their statusListCredential. Updating a status
+// list is setting its bits and issuing it again.
+func IssueStatusListCredential(provider saotypes.DidProvider, id string, purpose string, listType string, list *StatusList) (string, error) {
+	if listType != StatusList2021 && listType != BitstringStatusList {
+		return "", xerrors.Errorf("unsupported status list type %s", listType)
+	}
+	encodedList, err := list.Encode(listType)
+	if err != nil {
+		return "", err
+	}
+	context := []any{CredentialsContext}
+	if listType == StatusList2021 {
+		context = append(context, proof.StatusList2021Context)
+	}
+	return IssueCredential(provider, Credential{
+		Context: context,
+		Id:      id,
+		Type:    Types{VerifiableCredentialType, listType + "Credential"},
+		CredentialSubject: map[string]any{
+			"id":            id + "#list",
+			"type":          listType,
+			"statusPurpose": purpose,
+			"encodedList":   encodedList,
+		},
+	})
+}
+
+// checkStatus fetches and verifies the status list credential of the entry
+// and fails if the credential's bit is set.
+func checkStatus(credential Credential, resolver saotypes.DidResolver, options VerifyOptions) error {
+	status := credential.CredentialStatus
+	if status.Type != StatusList2021+"Entry" && status.Type != BitstringStatusList+"Entry" {
+		return xerrors.Errorf("unsupported credentialStatus type %s", status.Type)
+	}
+	if options.StatusListFetcher == nil {
+		return xerrors.New("no status list fetcher configured")
+	}
+	index, err := strconv.Atoi(status.StatusListIndex)
+	if err != nil {
+		return xerrors.Errorf("invalid statusListIndex: %v", err)
+	}
+	listCredential, err := options.StatusListFetcher.FetchStatusList(status.StatusListCredential)
+	if err != nil {
+		return err
+	}
+	// status lists do not carry a status themselves
+	options.StatusListFetcher = nil
+	listResult, err := VerifyCredential(listCredential, resolver, options)
+	if err != nil {
+		return xerrors.Errorf("status list credential: %v", err)
+	}
+	if listResult.Credential.Id != status.StatusListCredential {
+		return xerrors.Errorf("status list credential is not %s", status.StatusListCredential)
+	}
+	listType := strings.TrimSuffix(status.Type, "Entry")
+	if !listResult.Credential.Type.Contains(listType + "Credential") {
+		return xerrors.Errorf("status list credential is not a %sCredential", listType)
+	}
+	if listResult.Credential.Issuer != credential.Issuer {
+		return xerrors.New("status list credential issued by another issuer")
+	}
+	subject := listResult.Credential.CredentialSubject
+	if subject["statusPurpose"] != status.StatusPurpose {
+		return xerrors.Errorf("status list purpose is not %s", status.StatusPurpose)
+	}
+	encodedList, _ := subject["encodedList"].(string)
+	list, err := DecodeStatusList(encodedList)
+	if err != nil {
+		return err
+	}
+	set, err := list.Get(index)
+	if err != nil {
+		return err
+	}
+	if set {
+		return xerrors.Errorf("credential status is %s", status.StatusPurpose)
+	}
+	return nil
+}
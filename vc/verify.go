@@ -0,0 +1,277 @@
+package vc
+
+import (
+	"encoding/json"
+	"time"
+
+	did "github.com/SaoNetwork/sao-did"
+	"github.com/SaoNetwork/sao-did/jwt"
+	"github.com/SaoNetwork/sao-did/proof"
+	saotypes "github.com/SaoNetwork/sao-did/types"
+	"github.com/SaoNetwork/sao-did/util"
+	"golang.org/x/xerrors"
+)
+
+// Names of the checks recorded in a VerificationResult.
+const (
+	CheckFormat          = "format"
+	CheckSignature       = "signature"
+	CheckIssuer          = "issuer"
+	CheckAssertionMethod = "assertionMethod"
+	CheckIssuanceDate    = "issuanceDate"
+	CheckExpirationDate  = "expirationDate"
+)
+
+// ProofVerifier verifies the embedded proof of a JSON credential and
+// returns the id of the verification method that created it.
+type ProofVerifier interface {
+	VerifyProof(document map[string]any, resolver saotypes.DidResolver) (string, error)
+}
+
+type VerifyOptions struct {
+	// Clock is used for the validity period checks, defaults to the system clock.
+	Clock saotypes.Clock
+	// ProofVerifier verifies embedded proofs, defaults to the verifier of
+	// all proof suites of the proof package.
+	ProofVerifier ProofVerifier
+	// StatusListFetcher retrieves status lists, required for credentials
+	// with a credentialStatus.
+	StatusListFetcher StatusListFetcher
+}
+
+type Check struct {
+	Name   string
+	Passed bool
+	Error  string
+}
+
+type Checks []Check
+
+func (c *Checks) record(name string, err error) error {
+	check := Check{Name: name, Passed: err == nil}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	*c = append(*c, check)
+	return err
+}
+
+// Failed returns the first failed check, if any.
+func (c Checks) Failed() *Check {
+	for i := range c {
+		if !c[i].Passed {
+			return &c[i]
+		}
+	}
+	return nil
+}
+
+func (c Checks) err() error {
+	if failed := c.Failed(); failed != nil {
+		return xerrors.Errorf("%s check failed: %s", failed.Name, failed.Error)
+	}
+	return nil
+}
+
+type VerificationResult struct {
+	Verified           bool
+	Format             string
+	Credential         Credential
+	Document           map[string]any
+	VerificationMethod string
+	Checks             Checks
+}
+
+// Failed returns the first failed check, if any.
+func (r VerificationResult) Failed() *Check {
+	return r.Checks.Failed()
+}
+
+// VerifyCredential verifies a vc-jwt or a JSON credential with an embedded
+// proof. The issuer is resolved with the resolver and the signing key must
+// be one of the issuer's assertion methods. Every check performed is
+// recorded in the result; the returned error is the first failed check.
+func VerifyCredential(credential string, resolver saotypes.DidResolver, options VerifyOptions) (VerificationResult, error) {
+	result := VerificationResult{}
+	if resolver == nil {
+		return result, xerrors.New("resolver is missing.")
+	}
+	clock := options.Clock
+	if clock == nil {
+		clock = saotypes.SystemClock{}
+	}
+
+	var jws saotypes.GeneralJWS
+	var err error
+	if isJWT(credential) {
+		result.Format = FormatJWT
+		jws, result.Document, err = decodeCredentialJWT(credential)
+	} else {
+		result.Format = FormatLDP
+		err = json.Unmarshal([]byte(credential), &result.Document)
+		if err == nil && result.Document["proof"] == nil {
+			err = xerrors.New("credential has no proof")
+		}
+	}
+	if err == nil {
+		err = fromMap(result.Document, &result.Credential)
+	}
+	if err == nil && result.Credential.Issuer == "" {
+		err = xerrors.New("credential has no issuer")
+	}
+	if err = result.Checks.record(CheckFormat, err); err != nil {
+		return result, err
+	}
+
+	result.VerificationMethod, err = verifySignature(result, jws, resolver, options)
+	result.Checks.record(CheckSignature, err)
+	if err == nil {
+		result.Checks.record(CheckIssuer, checkIssuer(result.VerificationMethod, string(result.Credential.Issuer)))
+		result.Checks.record(CheckAssertionMethod, checkAssertionMethod(result.VerificationMethod, resolver))
+	}
+	result.Checks.record(CheckIssuanceDate, checkIssuanceDate(result.Credential.IssuanceDate, clock.Now()))
+	result.Checks.record(CheckExpirationDate, checkExpirationDate(result.Credential.ExpirationDate, clock.Now()))
+	// the status list is only fetched for an otherwise valid credential, the
+	// issuer should not learn about presentations of forged ones
+	if result.Credential.CredentialStatus != nil && result.Failed() == nil {
+		result.Checks.record(CheckStatus, checkStatus(result.Credential, resolver, options))
+	}
+
+	if err = result.Checks.err(); err != nil {
+		return result, err
+	}
+	result.Verified = true
+	return result, nil
+}
+
+func decodeCredentialJWT(token string) (saotypes.GeneralJWS, map[string]any, error) {
+	var claims credentialClaims
+	jws, err := jwt.Decode(token, &claims)
+	if err != nil {
+		return jws, nil, err
+	}
+	vc := claims.Vc
+	if vc == nil {
+		return jws, nil, xerrors.New("jwt has no vc claim")
+	}
+	if err := mergeClaim(vc, "issuer", claims.Iss); err != nil {
+		return jws, nil, err
+	}
+	if err := mergeClaim(vc, "id", claims.Jti); err != nil {
+		return jws, nil, err
+	}
+	if claims.Nbf != 0 {
+		if err := mergeClaim(vc, "issuanceDate", formatUnix(claims.Nbf)); err != nil {
+			return jws, nil, err
+		}
+	}
+	if claims.Exp != 0 {
+		if err := mergeClaim(vc, "expirationDate", formatUnix(claims.Exp)); err != nil {
+			return jws, nil, err
+		}
+	}
+	if claims.Sub != "" {
+		subject, ok := vc["credentialSubject"].(map[string]any)
+		if !ok {
+			return jws, nil, xerrors.New("invalid credentialSubject")
+		}
+		if err := mergeClaim(subject, "id", claims.Sub); err != nil {
+			return jws, nil, err
+		}
+	}
+	return jws, vc, nil
+}
+
+// mergeClaim copies a registered JWT claim into the credential, the two
+// must agree when the credential already has the property.
+func mergeClaim(vc map[string]any, name string, value string) error {
+	if value == "" {
+		return nil
+	}
+	existing, ok := vc[name]
+	if !ok {
+		vc[name] = value
+		return nil
+	}
+	if issuer, ok := existing.(map[string]any); ok && name == "issuer" {
+		existing = issuer["id"]
+	}
+	if name == "issuanceDate" || name == "expirationDate" {
+		if s, ok := existing.(string); ok {
+			if t, err := unixTime(s); err == nil {
+				existing = formatUnix(t)
+			}
+		}
+	}
+	if existing != value {
+		return xerrors.Errorf("jwt claim does not match credential %s", name)
+	}
+	return nil
+}
+
+func verifySignature(result VerificationResult, jws saotypes.GeneralJWS, resolver saotypes.DidResolver, options VerifyOptions) (string, error) {
+	if result.Format == FormatJWT {
+		dm := did.NewDidManager(nil, resolver)
+		dm.Clock = options.Clock
+		return dm.VerifyJWS(jws)
+	}
+	verifier := options.ProofVerifier
+	if verifier == nil {
+		defaultVerifier, err := proof.NewVerifier()
+		if err != nil {
+			return "", err
+		}
+		verifier = defaultVerifier
+	}
+	return verifier.VerifyProof(result.Document, resolver)
+}
+
+func checkIssuer(verificationMethod string, issuer string) error {
+	signer, err := util.KidToDid(verificationMethod)
+	if err != nil {
+		return err
+	}
+	if signer != issuer {
+		return xerrors.Errorf("credential issued by %s but signed by %s", issuer, signer)
+	}
+	return nil
+}
+
+func checkAssertionMethod(verificationMethod string, resolver saotypes.DidResolver) error {
+	didResolutionResult := resolver.Resolve(verificationMethod, saotypes.DidResolutionOptions{})
+	if didResolutionResult.DidResolutionMetadata.Error != "" {
+		return xerrors.Errorf("resolve %s failed: %s", verificationMethod, didResolutionResult.DidResolutionMetadata.Error)
+	}
+	if !didResolutionResult.DidDocument.HasAssertionMethod(verificationMethod) {
+		return xerrors.Errorf("%s is not an assertion method of the issuer", verificationMethod)
+	}
+	return nil
+}
+
+func checkIssuanceDate(issuanceDate string, now time.Time) error {
+	if issuanceDate == "" {
+		return xerrors.New("missing issuanceDate")
+	}
+	issued, err := time.Parse(time.RFC3339, issuanceDate)
+	if err != nil {
+		return xerrors.Errorf("issuanceDate should be RFC3339 format: %v", err)
+	}
+	if now.Before(issued) {
+		return xerrors.Errorf("credential is not valid before %s", issuanceDate)
+	}
+	return nil
+}
+
+func checkExpirationDate(expirationDate string, now time.Time) error {
+	if expirationDate == "" {
+		return nil
+	}
+	expires, err := time.Parse(time.RFC3339, expirationDate)
+	if err != nil {
+		return xerrors.Errorf("expirationDate should be RFC3339 format: %v", err)
+	}
+	if !now.Before(expires) {
+		return xerrors.Errorf("credential expired at %s", expirationDate)
+	}
+	return nil
+}